@@ -0,0 +1,68 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import "bytes"
+
+// Canonicalize returns the canonical encoding of the data which must have been
+// written by Marshal from a value of the same type as v. v must be a pointer
+// to a struct and is set to the value decoded. Signatures should be computed
+// over the bytes returned so that any service encoding the same value
+// produces the same bytes.
+func Canonicalize(data []byte, v interface{}) ([]byte, error) {
+	err := Unmarshal(data, v)
+	if err != nil {
+		return nil, err
+	}
+	return marshalCanonical(v)
+}
+
+// VerifyCanonical returns ErrNotCanonical if the data, written by Marshal from
+// a value of the same type as v, is not in canonical form. v must be a pointer
+// to a struct and is set to the value decoded. Used to check signed data
+// before the signature is verified.
+func VerifyCanonical(data []byte, v interface{}) error {
+	b := bytes.NewBuffer(data)
+	err := NewDecoderWithOptions(b, Options{Canonical: true}).Decode(v)
+	if err != nil {
+		return err
+	}
+	if b.Len() > 0 {
+		return &ErrNotCanonical{Reason: "bytes remain after value"}
+	}
+
+	// Values that decode successfully may still differ from the canonical
+	// encoding, for example if a field implements encoding.BinaryMarshaler.
+	c, err := marshalCanonical(v)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(c, data) {
+		return &ErrNotCanonical{Reason: "bytes differ from canonical encoding"}
+	}
+	return nil
+}
+
+// marshalCanonical returns the encoding of v with the Canonical option set.
+func marshalCanonical(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	err := NewEncoderWithOptions(&b, Options{Canonical: true}).Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
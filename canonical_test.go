@@ -0,0 +1,136 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+type testCanonical struct {
+	Name    string            `swan:"1"`
+	Time    time.Time         `swan:"2"`
+	Compact time.Time         `swan:"3,timecompact"`
+	Labels  map[string]string `swan:"4"`
+	Flag    bool              `swan:"5"`
+}
+
+// TestCanonicalize verifies values with times in other zones are converted to
+// the same bytes as the UTC equivalent and that only canonical bytes verify.
+func TestCanonicalize(t *testing.T) {
+	z := time.FixedZone("", 2*60*60)
+	m := time.Date(2022, time.March, 4, 5, 6, 7, 8, z)
+	v := &testCanonical{
+		Name:    "A",
+		Time:    m,
+		Compact: m,
+		Labels:  map[string]string{"b": "2", "a": "1"},
+		Flag:    true}
+	d, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e *ErrNotCanonical
+	if err := VerifyCanonical(d, &testCanonical{}); !errors.As(err, &e) {
+		t.Fatalf("expected ErrNotCanonical but got '%v'", err)
+	}
+	c, err := Canonicalize(d, &testCanonical{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Time = m.UTC()
+	v.Compact = m.UTC()
+	u, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(c, u) {
+		t.Fatal("canonical bytes differ from UTC value")
+	}
+	var r testCanonical
+	if err := VerifyCanonical(c, &r); err != nil {
+		t.Fatal(err)
+	}
+	if !r.Time.Equal(m) || r.Time.Location() != time.UTC {
+		t.Fatalf("time '%s' incorrect", r.Time)
+	}
+	if err := VerifyCanonical(append(c, 0), &r); !errors.As(err, &e) {
+		t.Fatalf("expected ErrNotCanonical but got '%v'", err)
+	}
+}
+
+// TestCanonicalDecoder verifies ambiguous encodings are only rejected when
+// the Canonical option is set.
+func TestCanonicalDecoder(t *testing.T) {
+	check := func(err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var unsorted bytes.Buffer
+	check(WriteCount(&unsorted, 2))
+	for _, s := range []string{"b", "2", "a", "1"} {
+		check(WriteString(&unsorted, s))
+	}
+	var compact bytes.Buffer
+	check(WriteTimeCompact(&compact, time.Date(
+		2022, time.January, 1, 0, 0, 0, 0,
+		time.FixedZone("", 60))))
+	for _, c := range []struct {
+		name   string
+		data   []byte
+		varint bool
+		read   func(d *Decoder) error
+	}{
+		{"varint", []byte{0x80, 0x00}, true, func(d *Decoder) error {
+			_, err := d.ReadUvarint()
+			return err
+		}},
+		{"length", []byte{0x81, 0x00, 'A'}, true, func(d *Decoder) error {
+			_, err := d.ReadByteArray()
+			return err
+		}},
+		{"bool", []byte{2}, false, func(d *Decoder) error {
+			_, err := d.ReadBool()
+			return err
+		}},
+		{"map", unsorted.Bytes(), false, func(d *Decoder) error {
+			_, err := d.ReadStringMap()
+			return err
+		}},
+		{"compact", compact.Bytes(), false, func(d *Decoder) error {
+			_, err := d.ReadTimeCompact()
+			return err
+		}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			o := Options{Varint: c.varint}
+			err := c.read(NewDecoderWithOptions(bytes.NewBuffer(c.data), o))
+			if err != nil {
+				t.Fatal(err)
+			}
+			o.Canonical = true
+			err = c.read(NewDecoderWithOptions(bytes.NewBuffer(c.data), o))
+			var e *ErrNotCanonical
+			if !errors.As(err, &e) {
+				t.Fatalf("expected ErrNotCanonical but got '%v'", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,837 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	common "github.com/SWAN-community/common-go"
+)
+
+// The import path of the common package referenced by generated code.
+const commonPath = "github.com/SWAN-community/common-go"
+
+// The first line of files written by swangen. Files starting with the header
+// are ignored when the package is type checked so that stale or broken output
+// doesn't prevent it being regenerated.
+const header = "// Code generated by swangen. DO NOT EDIT."
+
+// The suffix of the Write* and Read* functions for each kind of basic type.
+var primitives = map[types.BasicKind]string{
+	types.String:  "String",
+	types.Bool:    "Bool",
+	types.Uint8:   "Byte",
+	types.Uint16:  "Uint16",
+	types.Uint32:  "Uint32",
+	types.Uint64:  "Uint64",
+	types.Int16:   "Int16",
+	types.Int32:   "Int32",
+	types.Int64:   "Int64",
+	types.Float32: "Float32",
+	types.Float64: "Float64",
+}
+
+// The Write* and Read* functions for each time encoding.
+var timeFunctions = map[string][2]string{
+	"":                              {"WriteTime", "ReadTime"},
+	common.EncodingTime:             {"WriteTime", "ReadTime"},
+	common.EncodingTimeCompact:      {"WriteTimeCompact", "ReadTimeCompact"},
+	common.EncodingDate:             {"WriteDate", "ReadDate"},
+	common.EncodingDateMinutes:      {"WriteDateToUInt32", "ReadDateFromUInt32"},
+	common.EncodingDateSeconds:      {"WriteDateSeconds", "ReadDateSeconds"},
+	common.EncodingDateHours:        {"WriteDateHours", "ReadDateHours"},
+	common.EncodingDateHours16:      {"WriteDateHours16", "ReadDateHours16"},
+	common.EncodingDateMilliseconds: {"WriteDateMilliseconds", "ReadDateMilliseconds"},
+}
+
+// The Write* and Read* functions for slices of basic types.
+var sliceFunctions = map[types.BasicKind][2]string{
+	types.String: {"WriteStrings", "ReadStrings"},
+	types.Uint32: {"WriteUint32s", "ReadUint32s"},
+}
+
+// The methods a type needs to be written with WriteMarshaller and read with
+// ReadMarshaller.
+var (
+	marshaler   = methodInterface("MarshalBinary", false, true)
+	unmarshaler = methodInterface("UnmarshalBinary", true, false)
+)
+
+// genStruct is a struct to generate methods for.
+type genStruct struct {
+	name   string
+	obj    types.Object
+	fields []genField
+}
+
+// genField is a swan tagged field of a struct.
+type genField struct {
+	order    int
+	name     string
+	typ      types.Type
+	encoding string
+}
+
+// generator accumulates the generated methods and the packages they reference
+// other than bytes, fmt and common.
+type generator struct {
+	buf       bytes.Buffer
+	pkg       *types.Package
+	packages  map[string]string     // names of referenced packages by path
+	generated map[types.Object]bool // structs being generated
+	inlining  map[string]bool       // structs being written inline
+	err       error                 // first error type checking the package
+}
+
+// generate returns the formatted source containing the MarshalBinary and
+// UnmarshalBinary methods for the structs in src. If names is empty every
+// struct with at least one swan tagged field is generated. The other files of
+// the package in the same directory are type checked with src so that the
+// underlying types of the fields are known.
+func generate(filename string, src []byte, names []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(filename)
+	files, err := packageFiles(fset, dir, filename, f.Name.Name)
+	if err != nil {
+		return nil, err
+	}
+	g := generator{
+		packages:  make(map[string]string),
+		generated: make(map[types.Object]bool),
+		inlining:  make(map[string]bool)}
+	info := &types.Info{Defs: make(map[*ast.Ident]types.Object)}
+	c := types.Config{
+		Importer: importer.ForCompiler(fset, "gc", exportLookup(dir)),
+
+		// Continue after errors as code in other files might use methods
+		// that are only in the generated file.
+		Error: func(err error) {
+			if g.err == nil {
+				g.err = err
+			}
+		}}
+	g.pkg, _ = c.Check(f.Name.Name, fset, append(files, f), info)
+	structs, err := findStructs(f, info, names)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range structs {
+		g.generated[s.obj] = true
+	}
+	for _, s := range structs {
+		err = g.marshal(s)
+		if err != nil {
+			return nil, err
+		}
+		err = g.unmarshal(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var h bytes.Buffer
+	fmt.Fprintf(&h, "%s\n\n", header)
+	fmt.Fprintf(&h, "package %s\n\n", f.Name.Name)
+	fmt.Fprintf(&h, "import (\n\"bytes\"\n\"fmt\"\n")
+	var paths []string
+	for p := range g.packages {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		if g.packages[p] != path.Base(p) {
+			fmt.Fprintf(&h, "%s ", g.packages[p])
+		}
+		fmt.Fprintf(&h, "%s\n", strconv.Quote(p))
+	}
+	fmt.Fprintf(&h, "\ncommon %s\n)\n", strconv.Quote(commonPath))
+	h.Write(g.buf.Bytes())
+	out, err := format.Source(h.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated source: %w", err)
+	}
+	return out, nil
+}
+
+// packageFiles returns the other files of the package in the directory that
+// match the build constraints, excluding tests and files written by swangen.
+func packageFiles(
+	fset *token.FileSet,
+	dir string,
+	filename string,
+	pkg string) ([]*ast.File, error) {
+	m, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	var files []*ast.File
+	for _, n := range m {
+		if filepath.Base(n) == filepath.Base(filename) ||
+			strings.HasSuffix(n, "_test.go") {
+			continue
+		}
+		if ok, err := build.Default.MatchFile(dir, filepath.Base(n)); !ok ||
+			err != nil {
+			continue
+		}
+		src, err := os.ReadFile(n)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.HasPrefix(src, []byte(header)) {
+			continue
+		}
+		f, err := parser.ParseFile(fset, n, src, 0)
+		if err != nil {
+			return nil, err
+		}
+		if f.Name.Name == pkg {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// exportLookup returns a function that opens the export data for an import
+// path. The go command is used to find the data so that packages in modules
+// are found in the same way as when the package is built.
+func exportLookup(dir string) func(string) (io.ReadCloser, error) {
+	return func(p string) (io.ReadCloser, error) {
+		c := exec.Command("go", "list", "-export", "-f", "{{.Export}}", p)
+		c.Dir = dir
+		var e bytes.Buffer
+		c.Stderr = &e
+		out, err := c.Output()
+		if err != nil {
+			return nil, fmt.Errorf(
+				"finding export data for '%s': %w: %s",
+				p,
+				err,
+				strings.TrimSpace(e.String()))
+		}
+		return os.Open(strings.TrimSpace(string(out)))
+	}
+}
+
+// findStructs returns the structs to generate in the order they are declared.
+func findStructs(
+	f *ast.File,
+	info *types.Info,
+	names []string) ([]*genStruct, error) {
+	all := len(names) == 0
+	want := make(map[string]bool)
+	for _, n := range names {
+		want[n] = true
+	}
+	var structs []*genStruct
+	for _, d := range f.Decls {
+		g, ok := d.(*ast.GenDecl)
+		if !ok || g.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range g.Specs {
+			t := spec.(*ast.TypeSpec)
+			if _, ok := t.Type.(*ast.StructType); !ok {
+				continue
+			}
+			o := info.Defs[t.Name]
+			st, ok := o.Type().Underlying().(*types.Struct)
+			if !ok {
+				continue
+			}
+			s, err := newGenStruct(t.Name.Name, st)
+			if err != nil {
+				return nil, err
+			}
+			s.obj = o
+			if want[s.name] || (all && len(s.fields) > 0) {
+				structs = append(structs, s)
+				delete(want, s.name)
+			}
+		}
+	}
+	if len(want) > 0 {
+		var missing []string
+		for n := range want {
+			missing = append(missing, n)
+		}
+		sort.Strings(missing)
+		return nil, fmt.Errorf("structs '%v' not found", missing)
+	}
+	if len(structs) == 0 {
+		return nil, fmt.Errorf("no structs with swan tags found")
+	}
+	return structs, nil
+}
+
+// newGenStruct returns the swan tagged fields of the struct sorted by order.
+func newGenStruct(name string, st *types.Struct) (*genStruct, error) {
+	s := &genStruct{name: name}
+	orders := make(map[int]string)
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		v, ok := reflect.StructTag(st.Tag(i)).Lookup(common.TagName)
+		if !ok || v == "-" {
+			continue
+		}
+		if f.Embedded() {
+			return nil, fmt.Errorf("embedded field in '%s' not supported", name)
+		}
+		t, err := common.ParseTag(v)
+		if err != nil {
+			return nil, fmt.Errorf("field '%s.%s': %w", name, f.Name(), err)
+		}
+		if !f.Exported() {
+			return nil, fmt.Errorf(
+				"field '%s.%s' is not exported",
+				name,
+				f.Name())
+		}
+		if o, ok := orders[t.Order]; ok {
+			return nil, fmt.Errorf(
+				"fields '%s' and '%s' of '%s' have order '%d'",
+				o,
+				f.Name(),
+				name,
+				t.Order)
+		}
+		orders[t.Order] = f.Name()
+		s.fields = append(s.fields, genField{
+			order:    t.Order,
+			name:     f.Name(),
+			typ:      f.Type(),
+			encoding: t.Encoding})
+	}
+	sort.SliceStable(s.fields, func(i, j int) bool {
+		return s.fields[i].order < s.fields[j].order
+	})
+	return s, nil
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// marshal generates the MarshalBinary and MarshalInline methods.
+func (g *generator) marshal(s *genStruct) error {
+	g.printf("\n// MarshalBinary returns the swan tagged fields of %s in the binary\n", s.name)
+	g.printf("// format.\n")
+	g.printf("func (v *%s) MarshalBinary() ([]byte, error) {\n", s.name)
+	g.printf("var b bytes.Buffer\n")
+	g.printf("if err := v.MarshalInline(common.NewEncoder(&b)); err != nil {\n")
+	g.printf("return nil, err\n}\n")
+	g.printf("return b.Bytes(), nil\n}\n")
+	g.printf("\n// MarshalInline writes the swan tagged fields of %s to the encoder\n", s.name)
+	g.printf("// without a length so that they are inline when nested in other structs.\n")
+	g.printf("func (v *%s) MarshalInline(e *common.Encoder) error {\n", s.name)
+	err := g.writeFields("v", s, 0)
+	if err != nil {
+		return err
+	}
+	g.printf("return nil\n}\n")
+	return nil
+}
+
+// unmarshal generates the UnmarshalBinary and UnmarshalInline methods.
+func (g *generator) unmarshal(s *genStruct) error {
+	g.printf("\n// UnmarshalBinary reads the swan tagged fields of %s from the binary\n", s.name)
+	g.printf("// format.\n")
+	g.printf("func (v *%s) UnmarshalBinary(data []byte) error {\n", s.name)
+	g.printf("b := bytes.NewBuffer(data)\n")
+	g.printf("if err := v.UnmarshalInline(common.NewDecoder(b)); err != nil {\n")
+	g.printf("return err\n}\n")
+	g.printf("if b.Len() > 0 {\n")
+	g.printf("return fmt.Errorf(\"'%%d' bytes remain after unmarshal\", b.Len())\n")
+	g.printf("}\nreturn nil\n}\n")
+	g.printf("\n// UnmarshalInline reads the swan tagged fields of %s from the decoder.\n", s.name)
+	g.printf("func (v *%s) UnmarshalInline(d *common.Decoder) error {\n", s.name)
+	if len(s.fields) > 0 {
+		g.printf("var err error\n")
+	}
+	err := g.readFields("v", s, 0)
+	if err != nil {
+		return err
+	}
+	g.printf("return nil\n}\n")
+	return nil
+}
+
+// writeFields generates the statements to write the fields of the struct s
+// referenced by the expression x.
+func (g *generator) writeFields(x string, s *genStruct, depth int) error {
+	for _, f := range s.fields {
+		err := g.write(x+"."+f.name, f.typ, f.encoding, depth)
+		if err != nil {
+			return fmt.Errorf("field '%s.%s': %w", s.name, f.name, err)
+		}
+	}
+	return nil
+}
+
+// readFields generates the statements to read the fields of the struct s
+// referenced by the expression x.
+func (g *generator) readFields(x string, s *genStruct, depth int) error {
+	for _, f := range s.fields {
+		err := g.read(x+"."+f.name, f.typ, f.encoding, depth)
+		if err != nil {
+			return fmt.Errorf("field '%s.%s': %w", s.name, f.name, err)
+		}
+	}
+	return nil
+}
+
+// write generates the statements to write the expression x of type t. Types
+// are matched in the same order as common.Marshal so that the bytes are the
+// same.
+func (g *generator) write(x string, t types.Type, enc string, depth int) error {
+	err := g.check(t)
+	if err != nil {
+		return err
+	}
+	if isTime(t) {
+		f, ok := timeFunctions[enc]
+		if !ok {
+			return fmt.Errorf("encoding '%s' not supported for time", enc)
+		}
+		g.writeCall(f[0], x)
+		return nil
+	}
+	if enc == common.EncodingUvarint {
+		if !isBasic(t, types.Uint64) {
+			return fmt.Errorf("encoding '%s' requires uint64", enc)
+		}
+		g.writeCall("WriteUvarint", g.convert(x, t))
+		return nil
+	}
+	if enc == common.EncodingStringL {
+		if !isBasic(t, types.String) {
+			return fmt.Errorf("encoding '%s' requires string", enc)
+		}
+		g.writeCall("WriteStringL", g.convert(x, t))
+		return nil
+	}
+	if enc != "" && !isSlice(t) {
+		return fmt.Errorf(
+			"encoding '%s' not supported for '%s'",
+			enc,
+			g.typeName(t))
+	}
+	if p, ok := t.(*types.Pointer); ok {
+		if !g.implements(p.Elem(), marshaler) {
+			return fmt.Errorf("type '%s' not supported", g.typeName(t))
+		}
+		g.printf("if %s == nil {\n", x)
+		g.printf("return fmt.Errorf(\"nil '%s' can't be marshalled\")\n}\n",
+			g.typeName(t))
+		g.writeCall("WriteMarshaller", x)
+		return nil
+	}
+	if g.inline(t, "MarshalInline") {
+		g.printf("if err := %s.MarshalInline(e); err != nil {\n", x)
+		g.printf("return err\n}\n")
+		return nil
+	}
+	if g.implements(t, marshaler) {
+		g.writeCall("WriteMarshaller", "&"+x)
+		return nil
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		if p, ok := primitives[u.Kind()]; ok {
+			g.writeCall("Write"+p, g.convert(x, t))
+			return nil
+		}
+	case *types.Slice:
+		if isBasic(u.Elem(), types.Uint8) {
+			if !isByte(u.Elem()) {
+				break
+			}
+			g.writeCall("WriteByteArray", x)
+			return nil
+		}
+		if f, ok := sliceFunction(u.Elem()); ok && enc == "" {
+			g.writeCall(f[0], x)
+			return nil
+		}
+		g.writeCall("WriteCount", "len("+x+")")
+		i := fmt.Sprintf("i%d", depth)
+		g.printf("for %s := range %s {\n", i, x)
+		err := g.write(x+"["+i+"]", u.Elem(), enc, depth+1)
+		if err != nil {
+			return err
+		}
+		g.printf("}\n")
+		return nil
+	case *types.Array:
+		if isByte(u.Elem()) {
+			g.writeCall("WriteByteArrayNoLength", x+"[:]")
+			return nil
+		}
+	case *types.Map:
+		if types.Identical(u, stringMap) {
+			g.writeCall("WriteStringMap", x)
+			return nil
+		}
+	case *types.Struct:
+		s, err := g.enter(t, u)
+		if err != nil {
+			return err
+		}
+		defer g.leave(t)
+		return g.writeFields(x, s, depth)
+	}
+	return fmt.Errorf("type '%s' not supported", g.typeName(t))
+}
+
+// read generates the statements to read into the expression x of type t.
+func (g *generator) read(x string, t types.Type, enc string, depth int) error {
+	err := g.check(t)
+	if err != nil {
+		return err
+	}
+	if isTime(t) {
+		f, ok := timeFunctions[enc]
+		if !ok {
+			return fmt.Errorf("encoding '%s' not supported for time", enc)
+		}
+		g.readCall(x, f[1])
+		return nil
+	}
+	if enc == common.EncodingUvarint {
+		if !isBasic(t, types.Uint64) {
+			return fmt.Errorf("encoding '%s' requires uint64", enc)
+		}
+		g.readConvert(x, t, "ReadUvarint", depth)
+		return nil
+	}
+	if enc == common.EncodingStringL {
+		if !isBasic(t, types.String) {
+			return fmt.Errorf("encoding '%s' requires string", enc)
+		}
+		g.readConvert(x, t, "ReadStringL", depth)
+		return nil
+	}
+	if enc != "" && !isSlice(t) {
+		return fmt.Errorf(
+			"encoding '%s' not supported for '%s'",
+			enc,
+			g.typeName(t))
+	}
+	if p, ok := t.(*types.Pointer); ok {
+		if !g.implements(p.Elem(), unmarshaler) {
+			return fmt.Errorf("type '%s' not supported", g.typeName(t))
+		}
+		g.printf("%s = new(%s)\n", x, g.typeName(p.Elem()))
+		g.readMarshaller(x)
+		return nil
+	}
+	if g.inline(t, "UnmarshalInline") {
+		g.enterCall()
+		g.printf("if err = %s.UnmarshalInline(d); err != nil {\n", x)
+		g.printf("return err\n}\n")
+		g.printf("d.Leave()\n")
+		return nil
+	}
+	if g.implements(t, unmarshaler) {
+		g.readMarshaller("&" + x)
+		return nil
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		if p, ok := primitives[u.Kind()]; ok {
+			g.readConvert(x, t, "Read"+p, depth)
+			return nil
+		}
+	case *types.Slice:
+		if isBasic(u.Elem(), types.Uint8) {
+			if !isByte(u.Elem()) {
+				break
+			}
+			g.readCall(x, "ReadByteArray")
+			g.printf("%s = append([]byte(nil), %s...)\n", x, x)
+			return nil
+		}
+		if f, ok := sliceFunction(u.Elem()); ok && enc == "" {
+			g.readCall(x, f[1])
+			return nil
+		}
+		// The capacity is limited and the depth tracked by the decoder in
+		// the same way as common.Unmarshal so that hostile counts and
+		// nesting are rejected.
+		c := fmt.Sprintf("c%d", depth)
+		n := fmt.Sprintf("n%d", depth)
+		i := fmt.Sprintf("i%d", depth)
+		e := fmt.Sprintf("e%d", depth)
+		g.printf("{\nvar %s, %s int\n", c, n)
+		g.printf("if %s, %s, err = d.ReadSliceCount(); err != nil {\n", c, n)
+		g.printf("return err\n}\n")
+		g.printf("%s = make(%s, 0, %s)\n", x, g.typeName(t), n)
+		g.printf("for %s := 0; %s < %s; %s++ {\n", i, i, c, i)
+		g.printf("var %s %s\n", e, g.typeName(u.Elem()))
+		err := g.read(e, u.Elem(), enc, depth+1)
+		if err != nil {
+			return err
+		}
+		g.printf("%s = append(%s, %s)\n}\n", x, x, e)
+		g.printf("d.Leave()\n}\n")
+		return nil
+	case *types.Array:
+		if isByte(u.Elem()) {
+			a := fmt.Sprintf("a%d", depth)
+			g.printf("{\nvar %s []byte\n", a)
+			g.printf("if %s, err = d.ReadByteArrayNoLength(len(%s)); err != nil {\n", a, x)
+			g.printf("return err\n}\n")
+			g.printf("copy(%s[:], %s)\n}\n", x, a)
+			return nil
+		}
+	case *types.Map:
+		if types.Identical(u, stringMap) {
+			g.readCall(x, "ReadStringMap")
+			return nil
+		}
+	case *types.Struct:
+		s, err := g.enter(t, u)
+		if err != nil {
+			return err
+		}
+		defer g.leave(t)
+		g.enterCall()
+		err = g.readFields(x, s, depth)
+		if err != nil {
+			return err
+		}
+		g.printf("d.Leave()\n")
+		return nil
+	}
+	return fmt.Errorf("type '%s' not supported", g.typeName(t))
+}
+
+// writeCall generates a call to the Encoder method f with the argument a.
+func (g *generator) writeCall(f string, a string) {
+	g.printf("if err := e.%s(%s); err != nil {\n", f, a)
+	g.printf("return err\n}\n")
+}
+
+// readCall generates a call to the Decoder method f assigning the result to
+// x.
+func (g *generator) readCall(x string, f string) {
+	g.printf("if %s, err = d.%s(); err != nil {\n", x, f)
+	g.printf("return err\n}\n")
+}
+
+// enterCall generates a call to the Decoder Enter method before the fields of
+// a nested struct are read, so that nesting counts towards the MaxDepth limit
+// in the same way as common.Unmarshal.
+func (g *generator) enterCall() {
+	g.printf("if err = d.Enter(); err != nil {\n")
+	g.printf("return err\n}\n")
+}
+
+// readConvert generates a call to the Read* function f for the basic
+// underlying type of t. If t is a named type the result is read into a
+// variable of the basic type and converted.
+func (g *generator) readConvert(x string, t types.Type, f string, depth int) {
+	u := t.Underlying()
+	if types.Identical(t, u) {
+		g.readCall(x, f)
+		return
+	}
+	v := fmt.Sprintf("v%d", depth)
+	g.printf("{\nvar %s %s\n", v, g.typeName(u))
+	g.readCall(v, f)
+	g.printf("%s = %s(%s)\n}\n", x, g.typeName(t), v)
+}
+
+// readMarshaller generates a call to ReadMarshaller with the argument a.
+func (g *generator) readMarshaller(a string) {
+	g.printf("if err = d.ReadMarshaller(%s); err != nil {\n", a)
+	g.printf("return err\n}\n")
+}
+
+// convert returns the expression x converted to the basic underlying type of
+// t if t is a named type.
+func (g *generator) convert(x string, t types.Type) string {
+	u := t.Underlying()
+	if types.Identical(t, u) {
+		return x
+	}
+	return g.typeName(u) + "(" + x + ")"
+}
+
+// implements returns true if t or a pointer to t has the methods of the
+// interface. Structs being generated implement both encoding.BinaryMarshaler
+// and encoding.BinaryUnmarshaler.
+func (g *generator) implements(t types.Type, i *types.Interface) bool {
+	if n, ok := t.(*types.Named); ok && g.generated[n.Obj()] {
+		return true
+	}
+	return types.Implements(t, i) || types.Implements(types.NewPointer(t), i)
+}
+
+// inline returns true if the fields of t are written inline by the method,
+// which is MarshalInline or UnmarshalInline. Structs being generated have both
+// methods.
+func (g *generator) inline(t types.Type, method string) bool {
+	n, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	if g.generated[n.Obj()] {
+		return true
+	}
+	o, _, _ := types.LookupFieldOrMethod(types.NewPointer(t), true, nil, method)
+	_, ok = o.(*types.Func)
+	return ok
+}
+
+// enter returns the swan tagged fields of the struct type t, with underlying
+// type u, that is written inline without methods. Returns an error if t is
+// already being written as the generated code would never end.
+func (g *generator) enter(t types.Type, u *types.Struct) (*genStruct, error) {
+	n := g.typeName(t)
+	if g.inlining[n] {
+		return nil, fmt.Errorf("recursive type '%s' must be generated", n)
+	}
+	g.inlining[n] = true
+	return newGenStruct(n, u)
+}
+
+// leave is called when the fields of the struct type t have been written.
+func (g *generator) leave(t types.Type) {
+	delete(g.inlining, g.typeName(t))
+}
+
+// check returns the first error type checking the package if the type could
+// not be resolved.
+func (g *generator) check(t types.Type) error {
+	if b, ok := t.(*types.Basic); ok && b.Kind() == types.Invalid {
+		if g.err != nil {
+			return g.err
+		}
+		return fmt.Errorf("type not resolved")
+	}
+	return nil
+}
+
+// typeName returns the source of the type t recording the packages it
+// references so that they can be imported.
+func (g *generator) typeName(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == g.pkg {
+			return ""
+		}
+		if p.Path() != commonPath {
+			g.packages[p.Path()] = p.Name()
+		}
+		return p.Name()
+	})
+}
+
+// The type map[string]string.
+var stringMap = types.NewMap(types.Typ[types.String], types.Typ[types.String])
+
+// methodInterface returns an interface with the single method name which has
+// an optional []byte parameter and returns an optional []byte and an error.
+func methodInterface(name string, param bool, result bool) *types.Interface {
+	b := types.NewSlice(types.Typ[types.Byte])
+	var params, results []*types.Var
+	if param {
+		params = append(params, types.NewVar(token.NoPos, nil, "", b))
+	}
+	if result {
+		results = append(results, types.NewVar(token.NoPos, nil, "", b))
+	}
+	results = append(results, types.NewVar(
+		token.NoPos,
+		nil,
+		"",
+		types.Universe.Lookup("error").Type()))
+	f := types.NewFunc(token.NoPos, nil, name, types.NewSignatureType(
+		nil,
+		nil,
+		nil,
+		types.NewTuple(params...),
+		types.NewTuple(results...),
+		false))
+	return types.NewInterfaceType([]*types.Func{f}, nil).Complete()
+}
+
+// sliceFunction returns the Write* and Read* functions for slices with
+// elements of type t if there are any.
+func sliceFunction(t types.Type) ([2]string, bool) {
+	for k, f := range sliceFunctions {
+		if types.Identical(t, types.Typ[k]) {
+			return f, true
+		}
+	}
+	return [2]string{}, false
+}
+
+// isTime returns true if the type is time.Time.
+func isTime(t types.Type) bool {
+	n, ok := t.(*types.Named)
+	return ok &&
+		n.Obj().Pkg() != nil &&
+		n.Obj().Pkg().Path() == "time" &&
+		n.Obj().Name() == "Time"
+}
+
+// isSlice returns true if the type is a slice other than a byte slice. Tag
+// encodings of slices apply to their elements.
+func isSlice(t types.Type) bool {
+	s, ok := t.Underlying().(*types.Slice)
+	return ok && !isBasic(s.Elem(), types.Uint8)
+}
+
+// isByte returns true if the type is byte or uint8.
+func isByte(t types.Type) bool {
+	return types.Identical(t, types.Typ[types.Uint8])
+}
+
+// isBasic returns true if the underlying type is a basic type of kind k.
+func isBasic(t types.Type, k types.BasicKind) bool {
+	return basicKind(t) == k
+}
+
+// basicKind returns the kind of the underlying type if it is a basic type,
+// otherwise types.Invalid.
+func basicKind(t types.Type) types.BasicKind {
+	if b, ok := t.Underlying().(*types.Basic); ok {
+		return b.Kind()
+	}
+	return types.Invalid
+}
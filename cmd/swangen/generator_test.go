@@ -0,0 +1,189 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// The input files and the golden files containing the expected output. The
+// golden files are compiled and tested in their own packages.
+var goldenFiles = []struct {
+	input  string
+	golden string
+	names  []string
+}{
+	{"internal/example/record.go", "internal/example/record_swan.go", nil},
+}
+
+// TestGolden verifies the generated source matches the golden files, is
+// deterministic and is gofmt-clean. Run with -update to regenerate the golden
+// files after a change to the generator.
+func TestGolden(t *testing.T) {
+	for _, g := range goldenFiles {
+		t.Run(filepath.Base(g.input), func(t *testing.T) {
+			src, err := os.ReadFile(g.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := generate(g.input, src, g.names)
+			if err != nil {
+				t.Fatal(err)
+			}
+			again, err := generate(g.input, src, g.names)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out, again) {
+				t.Fatal("output not deterministic")
+			}
+			f, err := format.Source(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out, f) {
+				t.Fatal("output not gofmt-clean")
+			}
+			if *update {
+				err = os.WriteFile(g.golden, out, 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(g.golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out, want) {
+				t.Fatalf("output differs from '%s', run with -update", g.golden)
+			}
+		})
+	}
+}
+
+// TestGenerateTypes verifies the -type names select the structs generated.
+func TestGenerateTypes(t *testing.T) {
+	src := `package p
+type A struct {
+	V string ` + "`swan:\"1\"`" + `
+}
+type B struct {
+	V string ` + "`swan:\"1\"`" + `
+}
+type C struct {
+	V string
+}
+`
+	t.Run("all tagged", func(t *testing.T) {
+		out, err := generate("p.go", []byte(src), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range []string{"*A)", "*B)"} {
+			if !strings.Contains(string(out), s) {
+				t.Fatalf("'%s' not generated", s)
+			}
+		}
+		if strings.Contains(string(out), "*C)") {
+			t.Fatal("untagged struct generated")
+		}
+	})
+	t.Run("named", func(t *testing.T) {
+		out, err := generate("p.go", []byte(src), []string{"B", "C"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(out), "*A)") {
+			t.Fatal("unnamed struct generated")
+		}
+		for _, s := range []string{"*B)", "*C)"} {
+			if !strings.Contains(string(out), s) {
+				t.Fatalf("'%s' not generated", s)
+			}
+		}
+	})
+	t.Run("named first", func(t *testing.T) {
+		out, err := generate("p.go", []byte(src), []string{"A"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(out), "*B)") {
+			t.Fatal("unnamed struct generated")
+		}
+	})
+	t.Run("missing", func(t *testing.T) {
+		if _, err := generate("p.go", []byte(src), []string{"D"}); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+// TestGenerateErrors verifies unsupported structs result in errors.
+func TestGenerateErrors(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		field string
+		decls string
+	}{
+		{"unexported", "v string `swan:\"1\"`", ""},
+		{"duplicate order", "A string `swan:\"1\"`\nB string `swan:\"1\"`", ""},
+		{"invalid tag", "A string `swan:\"x\"`", ""},
+		{"unknown encoding", "A time.Time `swan:\"1,unknown\"`", ""},
+		{"encoding not supported", "A string `swan:\"1,date\"`", ""},
+		{"uvarint not uint64", "A uint32 `swan:\"1,uvarint\"`", ""},
+		{"map", "A map[string]int `swan:\"1\"`", ""},
+		{"array", "A [2]uint16 `swan:\"1\"`", ""},
+		{"no tags", "A string", ""},
+		{"named int", "A N `swan:\"1\"`", "type N int"},
+		{
+			"named struct",
+			"A N `swan:\"1\"`",
+			"type N struct {\nA int `swan:\"1\"`\n}"},
+		{"named pointer", "A *N `swan:\"1\"`", "type N uint16"},
+		{"undefined", "A N `swan:\"1\"`", ""},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			src := "package p\n"
+			if strings.Contains(c.field, "time.") {
+				src += "import \"time\"\n"
+			}
+			src += "type S struct {\n" + c.field + "\n}\n" + c.decls + "\n"
+			if _, err := generate("p.go", []byte(src), nil); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+
+	// A recursive struct that isn't generated would be written inline
+	// forever.
+	t.Run("recursive", func(t *testing.T) {
+		src := "package p\ntype S struct {\nA N `swan:\"1\"`\n}\n" +
+			"type N struct {\nA []N `swan:\"1\"`\n}\n"
+		_, err := generate("p.go", []byte(src), []string{"S"})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
@@ -0,0 +1,25 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package example
+
+// Plain has swan tags but no generated methods as there is no go:generate
+// directive in this file. It is written inline by common.Marshal and by the
+// methods generated for Record.
+type Plain struct {
+	Name   string `swan:"1"`
+	Status Status `swan:"2"`
+}
@@ -0,0 +1,89 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+// Package example contains structs used to test the code generated by swangen.
+package example
+
+import "time"
+
+//go:generate go run github.com/SWAN-community/common-go/cmd/swangen
+
+// Marshaller implements encoding.BinaryMarshaler by hand.
+type Marshaller struct {
+	Value string
+}
+
+func (m *Marshaller) MarshalBinary() ([]byte, error) {
+	return []byte(m.Value), nil
+}
+
+func (m *Marshaller) UnmarshalBinary(data []byte) error {
+	m.Value = string(data)
+	return nil
+}
+
+// Status is a named type with a basic underlying type.
+type Status uint16
+
+// Inner is nested within Record.
+type Inner struct {
+	Name  string `swan:"1"`
+	Count uint16 `swan:"2"`
+}
+
+// Record contains a field of every type supported by swangen.
+type Record struct {
+	Ignored     string
+	Strings     []string          `swan:"3"`
+	Name        string            `swan:"1"`
+	Data        []byte            `swan:"2"`
+	Arrays      [][]byte          `swan:"4"`
+	Fixed       [4]byte           `swan:"5"`
+	Bool        bool              `swan:"6"`
+	Byte        byte              `swan:"7"`
+	U16         uint16            `swan:"8"`
+	U32         uint32            `swan:"9"`
+	U64         uint64            `swan:"10"`
+	I16         int16             `swan:"11"`
+	I32         int32             `swan:"12"`
+	I64         int64             `swan:"13"`
+	F32         float32           `swan:"14"`
+	F64         float64           `swan:"15"`
+	Varint      uint64            `swan:"16,uvarint"`
+	Time        time.Time         `swan:"17"`
+	Compact     time.Time         `swan:"18,timecompact"`
+	Date        time.Time         `swan:"19,date"`
+	Minutes     time.Time         `swan:"20,dateminutes"`
+	Seconds     time.Time         `swan:"21,dateseconds"`
+	Hours       time.Time         `swan:"22,datehours"`
+	Hours16     time.Time         `swan:"23,datehours16"`
+	Millis      time.Time         `swan:"24,datemilliseconds"`
+	Inner       Inner             `swan:"25"`
+	Inners      []Inner           `swan:"26"`
+	Marshaller  Marshaller        `swan:"27"`
+	MarshallerP *Marshaller       `swan:"28"`
+	Dates       []time.Time       `swan:"29,date"`
+	Matrix      [][]uint16        `swan:"30"`
+	StringL     string            `swan:"31,stringl"`
+	Labels      map[string]string `swan:"32"`
+	Uint32s     []uint32          `swan:"33"`
+	Status      Status            `swan:"34"`
+	Statuses    []Status          `swan:"35"`
+	Timeout     time.Duration     `swan:"36"`
+	Plain       Plain             `swan:"37"`
+	Plains      []Plain           `swan:"38"`
+	Skip        string            `swan:"-"`
+}
@@ -0,0 +1,498 @@
+// Code generated by swangen. DO NOT EDIT.
+
+package example
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	common "github.com/SWAN-community/common-go"
+)
+
+// MarshalBinary returns the swan tagged fields of Inner in the binary
+// format.
+func (v *Inner) MarshalBinary() ([]byte, error) {
+	var b bytes.Buffer
+	if err := v.MarshalInline(common.NewEncoder(&b)); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// MarshalInline writes the swan tagged fields of Inner to the encoder
+// without a length so that they are inline when nested in other structs.
+func (v *Inner) MarshalInline(e *common.Encoder) error {
+	if err := e.WriteString(v.Name); err != nil {
+		return err
+	}
+	if err := e.WriteUint16(v.Count); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UnmarshalBinary reads the swan tagged fields of Inner from the binary
+// format.
+func (v *Inner) UnmarshalBinary(data []byte) error {
+	b := bytes.NewBuffer(data)
+	if err := v.UnmarshalInline(common.NewDecoder(b)); err != nil {
+		return err
+	}
+	if b.Len() > 0 {
+		return fmt.Errorf("'%d' bytes remain after unmarshal", b.Len())
+	}
+	return nil
+}
+
+// UnmarshalInline reads the swan tagged fields of Inner from the decoder.
+func (v *Inner) UnmarshalInline(d *common.Decoder) error {
+	var err error
+	if v.Name, err = d.ReadString(); err != nil {
+		return err
+	}
+	if v.Count, err = d.ReadUint16(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// MarshalBinary returns the swan tagged fields of Record in the binary
+// format.
+func (v *Record) MarshalBinary() ([]byte, error) {
+	var b bytes.Buffer
+	if err := v.MarshalInline(common.NewEncoder(&b)); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// MarshalInline writes the swan tagged fields of Record to the encoder
+// without a length so that they are inline when nested in other structs.
+func (v *Record) MarshalInline(e *common.Encoder) error {
+	if err := e.WriteString(v.Name); err != nil {
+		return err
+	}
+	if err := e.WriteByteArray(v.Data); err != nil {
+		return err
+	}
+	if err := e.WriteStrings(v.Strings); err != nil {
+		return err
+	}
+	if err := e.WriteCount(len(v.Arrays)); err != nil {
+		return err
+	}
+	for i0 := range v.Arrays {
+		if err := e.WriteByteArray(v.Arrays[i0]); err != nil {
+			return err
+		}
+	}
+	if err := e.WriteByteArrayNoLength(v.Fixed[:]); err != nil {
+		return err
+	}
+	if err := e.WriteBool(v.Bool); err != nil {
+		return err
+	}
+	if err := e.WriteByte(v.Byte); err != nil {
+		return err
+	}
+	if err := e.WriteUint16(v.U16); err != nil {
+		return err
+	}
+	if err := e.WriteUint32(v.U32); err != nil {
+		return err
+	}
+	if err := e.WriteUint64(v.U64); err != nil {
+		return err
+	}
+	if err := e.WriteInt16(v.I16); err != nil {
+		return err
+	}
+	if err := e.WriteInt32(v.I32); err != nil {
+		return err
+	}
+	if err := e.WriteInt64(v.I64); err != nil {
+		return err
+	}
+	if err := e.WriteFloat32(v.F32); err != nil {
+		return err
+	}
+	if err := e.WriteFloat64(v.F64); err != nil {
+		return err
+	}
+	if err := e.WriteUvarint(v.Varint); err != nil {
+		return err
+	}
+	if err := e.WriteTime(v.Time); err != nil {
+		return err
+	}
+	if err := e.WriteTimeCompact(v.Compact); err != nil {
+		return err
+	}
+	if err := e.WriteDate(v.Date); err != nil {
+		return err
+	}
+	if err := e.WriteDateToUInt32(v.Minutes); err != nil {
+		return err
+	}
+	if err := e.WriteDateSeconds(v.Seconds); err != nil {
+		return err
+	}
+	if err := e.WriteDateHours(v.Hours); err != nil {
+		return err
+	}
+	if err := e.WriteDateHours16(v.Hours16); err != nil {
+		return err
+	}
+	if err := e.WriteDateMilliseconds(v.Millis); err != nil {
+		return err
+	}
+	if err := v.Inner.MarshalInline(e); err != nil {
+		return err
+	}
+	if err := e.WriteCount(len(v.Inners)); err != nil {
+		return err
+	}
+	for i0 := range v.Inners {
+		if err := v.Inners[i0].MarshalInline(e); err != nil {
+			return err
+		}
+	}
+	if err := e.WriteMarshaller(&v.Marshaller); err != nil {
+		return err
+	}
+	if v.MarshallerP == nil {
+		return fmt.Errorf("nil '*Marshaller' can't be marshalled")
+	}
+	if err := e.WriteMarshaller(v.MarshallerP); err != nil {
+		return err
+	}
+	if err := e.WriteCount(len(v.Dates)); err != nil {
+		return err
+	}
+	for i0 := range v.Dates {
+		if err := e.WriteDate(v.Dates[i0]); err != nil {
+			return err
+		}
+	}
+	if err := e.WriteCount(len(v.Matrix)); err != nil {
+		return err
+	}
+	for i0 := range v.Matrix {
+		if err := e.WriteCount(len(v.Matrix[i0])); err != nil {
+			return err
+		}
+		for i1 := range v.Matrix[i0] {
+			if err := e.WriteUint16(v.Matrix[i0][i1]); err != nil {
+				return err
+			}
+		}
+	}
+	if err := e.WriteStringL(v.StringL); err != nil {
+		return err
+	}
+	if err := e.WriteStringMap(v.Labels); err != nil {
+		return err
+	}
+	if err := e.WriteUint32s(v.Uint32s); err != nil {
+		return err
+	}
+	if err := e.WriteUint16(uint16(v.Status)); err != nil {
+		return err
+	}
+	if err := e.WriteCount(len(v.Statuses)); err != nil {
+		return err
+	}
+	for i0 := range v.Statuses {
+		if err := e.WriteUint16(uint16(v.Statuses[i0])); err != nil {
+			return err
+		}
+	}
+	if err := e.WriteInt64(int64(v.Timeout)); err != nil {
+		return err
+	}
+	if err := e.WriteString(v.Plain.Name); err != nil {
+		return err
+	}
+	if err := e.WriteUint16(uint16(v.Plain.Status)); err != nil {
+		return err
+	}
+	if err := e.WriteCount(len(v.Plains)); err != nil {
+		return err
+	}
+	for i0 := range v.Plains {
+		if err := e.WriteString(v.Plains[i0].Name); err != nil {
+			return err
+		}
+		if err := e.WriteUint16(uint16(v.Plains[i0].Status)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalBinary reads the swan tagged fields of Record from the binary
+// format.
+func (v *Record) UnmarshalBinary(data []byte) error {
+	b := bytes.NewBuffer(data)
+	if err := v.UnmarshalInline(common.NewDecoder(b)); err != nil {
+		return err
+	}
+	if b.Len() > 0 {
+		return fmt.Errorf("'%d' bytes remain after unmarshal", b.Len())
+	}
+	return nil
+}
+
+// UnmarshalInline reads the swan tagged fields of Record from the decoder.
+func (v *Record) UnmarshalInline(d *common.Decoder) error {
+	var err error
+	if v.Name, err = d.ReadString(); err != nil {
+		return err
+	}
+	if v.Data, err = d.ReadByteArray(); err != nil {
+		return err
+	}
+	v.Data = append([]byte(nil), v.Data...)
+	if v.Strings, err = d.ReadStrings(); err != nil {
+		return err
+	}
+	{
+		var c0, n0 int
+		if c0, n0, err = d.ReadSliceCount(); err != nil {
+			return err
+		}
+		v.Arrays = make([][]byte, 0, n0)
+		for i0 := 0; i0 < c0; i0++ {
+			var e0 []byte
+			if e0, err = d.ReadByteArray(); err != nil {
+				return err
+			}
+			e0 = append([]byte(nil), e0...)
+			v.Arrays = append(v.Arrays, e0)
+		}
+		d.Leave()
+	}
+	{
+		var a0 []byte
+		if a0, err = d.ReadByteArrayNoLength(len(v.Fixed)); err != nil {
+			return err
+		}
+		copy(v.Fixed[:], a0)
+	}
+	if v.Bool, err = d.ReadBool(); err != nil {
+		return err
+	}
+	if v.Byte, err = d.ReadByte(); err != nil {
+		return err
+	}
+	if v.U16, err = d.ReadUint16(); err != nil {
+		return err
+	}
+	if v.U32, err = d.ReadUint32(); err != nil {
+		return err
+	}
+	if v.U64, err = d.ReadUint64(); err != nil {
+		return err
+	}
+	if v.I16, err = d.ReadInt16(); err != nil {
+		return err
+	}
+	if v.I32, err = d.ReadInt32(); err != nil {
+		return err
+	}
+	if v.I64, err = d.ReadInt64(); err != nil {
+		return err
+	}
+	if v.F32, err = d.ReadFloat32(); err != nil {
+		return err
+	}
+	if v.F64, err = d.ReadFloat64(); err != nil {
+		return err
+	}
+	if v.Varint, err = d.ReadUvarint(); err != nil {
+		return err
+	}
+	if v.Time, err = d.ReadTime(); err != nil {
+		return err
+	}
+	if v.Compact, err = d.ReadTimeCompact(); err != nil {
+		return err
+	}
+	if v.Date, err = d.ReadDate(); err != nil {
+		return err
+	}
+	if v.Minutes, err = d.ReadDateFromUInt32(); err != nil {
+		return err
+	}
+	if v.Seconds, err = d.ReadDateSeconds(); err != nil {
+		return err
+	}
+	if v.Hours, err = d.ReadDateHours(); err != nil {
+		return err
+	}
+	if v.Hours16, err = d.ReadDateHours16(); err != nil {
+		return err
+	}
+	if v.Millis, err = d.ReadDateMilliseconds(); err != nil {
+		return err
+	}
+	if err = d.Enter(); err != nil {
+		return err
+	}
+	if err = v.Inner.UnmarshalInline(d); err != nil {
+		return err
+	}
+	d.Leave()
+	{
+		var c0, n0 int
+		if c0, n0, err = d.ReadSliceCount(); err != nil {
+			return err
+		}
+		v.Inners = make([]Inner, 0, n0)
+		for i0 := 0; i0 < c0; i0++ {
+			var e0 Inner
+			if err = d.Enter(); err != nil {
+				return err
+			}
+			if err = e0.UnmarshalInline(d); err != nil {
+				return err
+			}
+			d.Leave()
+			v.Inners = append(v.Inners, e0)
+		}
+		d.Leave()
+	}
+	if err = d.ReadMarshaller(&v.Marshaller); err != nil {
+		return err
+	}
+	v.MarshallerP = new(Marshaller)
+	if err = d.ReadMarshaller(v.MarshallerP); err != nil {
+		return err
+	}
+	{
+		var c0, n0 int
+		if c0, n0, err = d.ReadSliceCount(); err != nil {
+			return err
+		}
+		v.Dates = make([]time.Time, 0, n0)
+		for i0 := 0; i0 < c0; i0++ {
+			var e0 time.Time
+			if e0, err = d.ReadDate(); err != nil {
+				return err
+			}
+			v.Dates = append(v.Dates, e0)
+		}
+		d.Leave()
+	}
+	{
+		var c0, n0 int
+		if c0, n0, err = d.ReadSliceCount(); err != nil {
+			return err
+		}
+		v.Matrix = make([][]uint16, 0, n0)
+		for i0 := 0; i0 < c0; i0++ {
+			var e0 []uint16
+			{
+				var c1, n1 int
+				if c1, n1, err = d.ReadSliceCount(); err != nil {
+					return err
+				}
+				e0 = make([]uint16, 0, n1)
+				for i1 := 0; i1 < c1; i1++ {
+					var e1 uint16
+					if e1, err = d.ReadUint16(); err != nil {
+						return err
+					}
+					e0 = append(e0, e1)
+				}
+				d.Leave()
+			}
+			v.Matrix = append(v.Matrix, e0)
+		}
+		d.Leave()
+	}
+	if v.StringL, err = d.ReadStringL(); err != nil {
+		return err
+	}
+	if v.Labels, err = d.ReadStringMap(); err != nil {
+		return err
+	}
+	if v.Uint32s, err = d.ReadUint32s(); err != nil {
+		return err
+	}
+	{
+		var v0 uint16
+		if v0, err = d.ReadUint16(); err != nil {
+			return err
+		}
+		v.Status = Status(v0)
+	}
+	{
+		var c0, n0 int
+		if c0, n0, err = d.ReadSliceCount(); err != nil {
+			return err
+		}
+		v.Statuses = make([]Status, 0, n0)
+		for i0 := 0; i0 < c0; i0++ {
+			var e0 Status
+			{
+				var v1 uint16
+				if v1, err = d.ReadUint16(); err != nil {
+					return err
+				}
+				e0 = Status(v1)
+			}
+			v.Statuses = append(v.Statuses, e0)
+		}
+		d.Leave()
+	}
+	{
+		var v0 int64
+		if v0, err = d.ReadInt64(); err != nil {
+			return err
+		}
+		v.Timeout = time.Duration(v0)
+	}
+	if err = d.Enter(); err != nil {
+		return err
+	}
+	if v.Plain.Name, err = d.ReadString(); err != nil {
+		return err
+	}
+	{
+		var v0 uint16
+		if v0, err = d.ReadUint16(); err != nil {
+			return err
+		}
+		v.Plain.Status = Status(v0)
+	}
+	d.Leave()
+	{
+		var c0, n0 int
+		if c0, n0, err = d.ReadSliceCount(); err != nil {
+			return err
+		}
+		v.Plains = make([]Plain, 0, n0)
+		for i0 := 0; i0 < c0; i0++ {
+			var e0 Plain
+			if err = d.Enter(); err != nil {
+				return err
+			}
+			if e0.Name, err = d.ReadString(); err != nil {
+				return err
+			}
+			{
+				var v1 uint16
+				if v1, err = d.ReadUint16(); err != nil {
+					return err
+				}
+				e0.Status = Status(v1)
+			}
+			d.Leave()
+			v.Plains = append(v.Plains, e0)
+		}
+		d.Leave()
+	}
+	return nil
+}
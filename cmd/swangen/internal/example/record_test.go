@@ -0,0 +1,200 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package example
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	common "github.com/SWAN-community/common-go"
+)
+
+func newRecord() *Record {
+	d := time.Date(2022, time.March, 4, 0, 0, 0, 0, time.UTC)
+	m := time.Date(2022, time.March, 4, 5, 6, 7, 8000000, time.UTC)
+	return &Record{
+		Name:        "Hello",
+		Data:        []byte{1, 2, 3},
+		Strings:     []string{"A", "B"},
+		Arrays:      [][]byte{{1}, {2, 3}},
+		Fixed:       [4]byte{9, 8, 7, 6},
+		Bool:        true,
+		Byte:        0x12,
+		U16:         0x1234,
+		U32:         0x12345678,
+		U64:         0x123456789ABCDEF0,
+		I16:         -16,
+		I32:         -32,
+		I64:         -64,
+		F32:         1.5,
+		F64:         -2.25,
+		Varint:      300,
+		Time:        m,
+		Compact:     m,
+		Date:        d,
+		Minutes:     m.Truncate(time.Minute),
+		Seconds:     m.Truncate(time.Second),
+		Hours:       m.Truncate(time.Hour),
+		Hours16:     m.Truncate(time.Hour),
+		Millis:      m,
+		Inner:       Inner{Name: "Inner", Count: 2},
+		Inners:      []Inner{{"X", 1}, {"Y", 2}},
+		Marshaller:  Marshaller{Value: "M"},
+		MarshallerP: &Marshaller{Value: "P"},
+		Dates:       []time.Time{d, d.AddDate(0, 0, 1)},
+		Matrix:      [][]uint16{{1, 2}, {}, {3}},
+		StringL:     "A\x00B",
+		Labels:      map[string]string{"b": "2", "a": "1"},
+		Uint32s:     []uint32{1, 0xFFFFFFFF},
+		Status:      7,
+		Statuses:    []Status{1, 2},
+		Timeout:     -time.Second,
+		Plain:       Plain{Name: "Plain", Status: 3},
+		Plains:      []Plain{{"A", 1}, {"B", 2}}}
+}
+
+// TestGenerated verifies the generated methods produce the same bytes as
+// common.Marshal and restore the tagged fields.
+func TestGenerated(t *testing.T) {
+	r := newRecord()
+	r.Ignored = "ignored"
+	r.Skip = "skip"
+	b, err := r.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := common.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(b, m) {
+		t.Fatal("generated and reflection marshal produced different bytes")
+	}
+	var u Record
+	if err := u.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	r.Ignored = ""
+	r.Skip = ""
+	if !reflect.DeepEqual(&u, r) {
+		t.Fatalf("unmarshalled '%v' != '%v'", u, *r)
+	}
+}
+
+// innerPlain has the same fields as Inner without generated methods.
+type innerPlain struct {
+	Name  string `swan:"1"`
+	Count uint16 `swan:"2"`
+}
+
+// TestGeneratedInline verifies nested structs are written inline without a
+// length whether or not they have generated methods, so that generating
+// methods for a nested struct doesn't change the layout of data written by
+// common.Marshal.
+func TestGeneratedInline(t *testing.T) {
+	g, err := common.Marshal(&struct {
+		I Inner `swan:"1"`
+	}{Inner{Name: "A", Count: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := common.Marshal(&struct {
+		I innerPlain `swan:"1"`
+	}{innerPlain{Name: "A", Count: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(g, p) {
+		t.Fatalf("'%x' != '%x'", g, p)
+	}
+	i, err := (&Inner{Name: "A", Count: 1}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(g, i) {
+		t.Fatalf("'%x' != '%x'", g, i)
+	}
+}
+
+// TestGeneratedErrors verifies the generated methods return errors for nil
+// pointers, truncated data and trailing bytes.
+func TestGeneratedErrors(t *testing.T) {
+	t.Run("nil pointer", func(t *testing.T) {
+		r := newRecord()
+		r.MarshallerP = nil
+		if _, err := r.MarshalBinary(); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	b, err := newRecord().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Run("truncated", func(t *testing.T) {
+		var u Record
+		if err := u.UnmarshalBinary(b[:len(b)-1]); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("trailing", func(t *testing.T) {
+		var u Record
+		if err := u.UnmarshalBinary(append(b, 0)); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+// TestGeneratedLimits verifies the generated methods apply the decoder limits
+// in the same way as common.Unmarshal so that hostile counts and nesting are
+// rejected rather than exhausting memory.
+func TestGeneratedLimits(t *testing.T) {
+	t.Run("count", func(t *testing.T) {
+		// Empty name, data and strings followed by a count of 2^28-1 arrays
+		// and no more bytes.
+		p := []byte{0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0x7f}
+		var v struct {
+			R Record `swan:"1"`
+		}
+		err := common.NewDecoderWithOptions(
+			bytes.NewBuffer(p),
+			common.Options{Varint: true}).Decode(&v)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("depth", func(t *testing.T) {
+		b, err := common.Marshal(&struct {
+			R Record `swan:"1"`
+		}{*newRecord()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v struct {
+			R Record `swan:"1"`
+		}
+		err = common.NewDecoderWithOptions(
+			bytes.NewBuffer(b),
+			common.Options{Limits: common.Limits{MaxDepth: 2}}).Decode(&v)
+		var l *common.ErrLimitExceeded
+		if !errors.As(err, &l) || l.Limit != "MaxDepth" {
+			t.Fatalf("expected MaxDepth error but got '%v'", err)
+		}
+	})
+}
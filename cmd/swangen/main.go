@@ -0,0 +1,86 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+// Swangen generates MarshalBinary and UnmarshalBinary methods for structs
+// with swan struct tags. The generated methods produce the same bytes as
+// common.Marshal without the cost of reflection.
+//
+// Usage:
+//
+//	//go:generate go run github.com/SWAN-community/common-go/cmd/swangen
+//
+// By default every struct in the file containing the go:generate directive
+// that has at least one swan tagged field is generated and the output is
+// written to a file with the same name and a _swan.go suffix.
+//
+// Flags:
+//
+//	-type    comma separated list of struct names to generate
+//	-output  name of the output file
+//
+// The package is type checked so that fields of named types such as
+// time.Duration are written with the Write* function for their underlying
+// type in the same way as common.Marshal. MarshalInline and UnmarshalInline
+// methods are also generated so that nested structs are written inline, as
+// common.Marshal does, whether or not they are generated. Fields of struct
+// types that implement encoding.BinaryMarshaler and
+// encoding.BinaryUnmarshaler are written with WriteMarshaller.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("swangen: ")
+	types := flag.String("type", "", "comma separated list of struct names")
+	output := flag.String("output", "", "output file name")
+	flag.Parse()
+
+	// Use the file provided or the file containing the go:generate directive.
+	input := flag.Arg(0)
+	if input == "" {
+		input = os.Getenv("GOFILE")
+	}
+	if input == "" {
+		log.Fatal("no input file")
+	}
+	if *output == "" {
+		*output = strings.TrimSuffix(input, ".go") + "_swan.go"
+	}
+
+	var names []string
+	if *types != "" {
+		names = strings.Split(*types, ",")
+	}
+	src, err := os.ReadFile(input)
+	if err != nil {
+		log.Fatal(err)
+	}
+	out, err := generate(input, src, names)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = os.WriteFile(*output, out, 0644)
+	if err != nil {
+		log.Fatal(fmt.Errorf("writing '%s': %w", *output, err))
+	}
+}
@@ -0,0 +1,127 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// Compression is the algorithm used by WriteCompressedByteArray. Written as a
+// single byte before the compressed data so the reader knows how to
+// decompress it.
+type Compression byte
+
+const (
+	CompressionNone Compression = 0 // the bytes are stored uncompressed
+	CompressionGzip Compression = 1 // the bytes are compressed with gzip
+)
+
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionGzip:
+		return "gzip"
+	}
+	return fmt.Sprintf("unknown(%d)", byte(c))
+}
+
+// WriteCompressedByteArray writes the compression algorithm as a byte followed
+// by the byte array compressed with the algorithm. Returns ErrTooLong if the
+// uncompressed byte array exceeds the decompression limit that the reader
+// will apply.
+func (e *Encoder) WriteCompressedByteArray(v []byte, c Compression) error {
+	m := e.o.Limits.decompressed()
+	if len(v) > m {
+		return &ErrTooLong{
+			Field:  "compressed byte array",
+			Length: int64(len(v)),
+			Limit:  int64(m)}
+	}
+	var d []byte
+	switch c {
+	case CompressionNone:
+		d = v
+	case CompressionGzip:
+		b := GetBuffer()
+		defer PutBuffer(b)
+		z := gzip.NewWriter(b)
+		_, err := z.Write(v)
+		if err != nil {
+			return err
+		}
+		err = z.Close()
+		if err != nil {
+			return err
+		}
+		d = b.Bytes()
+	default:
+		return fmt.Errorf("compression '%s' not supported", c)
+	}
+	err := e.WriteByte(byte(c))
+	if err != nil {
+		return err
+	}
+	return e.WriteByteArray(d)
+}
+
+// ReadCompressedByteArray reads a byte array written by
+// WriteCompressedByteArray and returns it decompressed. Returns
+// ErrLimitExceeded if the decompressed bytes would exceed the MaxDecompressed
+// or MaxLength limit. Decompression stops as soon as the limit is exceeded.
+func (d *Decoder) ReadCompressedByteArray() ([]byte, error) {
+	c, err := d.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	v, err := d.ReadByteArray()
+	if err != nil {
+		return nil, err
+	}
+	m := d.o.Limits.decompressed()
+	switch Compression(c) {
+	case CompressionNone:
+		if len(v) > m {
+			return nil, &ErrLimitExceeded{
+				Limit: "MaxDecompressed",
+				Value: int64(len(v)),
+				Max:   int64(m)}
+		}
+		return append([]byte(nil), v...), nil
+	case CompressionGzip:
+		z, err := gzip.NewReader(bytes.NewReader(v))
+		if err != nil {
+			return nil, err
+		}
+		defer z.Close()
+		r, err := io.ReadAll(io.LimitReader(z, int64(m)+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(r) > m {
+			return nil, &ErrLimitExceeded{
+				Limit: "MaxDecompressed",
+				Value: int64(len(r)),
+				Max:   int64(m)}
+		}
+		return r, nil
+	}
+	return nil, fmt.Errorf("compression '%s' not supported", Compression(c))
+}
@@ -0,0 +1,125 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestCompressedByteArray verifies byte arrays round trip with each algorithm
+// and that gzip reduces the size of repetitive data.
+func TestCompressedByteArray(t *testing.T) {
+	v := bytes.Repeat([]byte("OWID"), 1000)
+	for _, c := range []Compression{CompressionNone, CompressionGzip} {
+		t.Run(c.String(), func(t *testing.T) {
+			var b bytes.Buffer
+			if err := WriteCompressedByteArray(&b, v, c); err != nil {
+				t.Fatal(err)
+			}
+			if c == CompressionGzip && b.Len() >= len(v) {
+				t.Fatalf("compressed length '%d' not reduced", b.Len())
+			}
+			r, err := ReadCompressedByteArray(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(r, v) {
+				t.Fatal("decompressed bytes differ")
+			}
+			if b.Len() != 0 {
+				t.Fatalf("'%d' bytes remain", b.Len())
+			}
+		})
+	}
+}
+
+// TestCompressedByteArrayLimits verifies data that decompresses beyond the
+// limit fails when written and read.
+func TestCompressedByteArrayLimits(t *testing.T) {
+	v := make([]byte, 1<<20)
+	o := Options{Limits: Limits{MaxDecompressed: 1024}}
+	t.Run("write", func(t *testing.T) {
+		var b bytes.Buffer
+		err := NewEncoderWithOptions(&b, o).WriteCompressedByteArray(
+			v,
+			CompressionGzip)
+		var e *ErrTooLong
+		if !errors.As(err, &e) || e.Limit != 1024 {
+			t.Fatalf("expected ErrTooLong but got '%v'", err)
+		}
+	})
+	for _, c := range []Compression{CompressionNone, CompressionGzip} {
+		t.Run(c.String(), func(t *testing.T) {
+			var b bytes.Buffer
+			if err := WriteCompressedByteArray(&b, v, c); err != nil {
+				t.Fatal(err)
+			}
+			_, err := NewDecoderWithOptions(&b, o).ReadCompressedByteArray()
+			var e *ErrLimitExceeded
+			if !errors.As(err, &e) || e.Max != 1024 {
+				t.Fatalf("expected ErrLimitExceeded but got '%v'", err)
+			}
+		})
+	}
+	t.Run("max length", func(t *testing.T) {
+		var b bytes.Buffer
+		if err := WriteCompressedByteArray(&b, v, CompressionGzip); err != nil {
+			t.Fatal(err)
+		}
+		d := NewDecoderWithOptions(
+			&b,
+			Options{Limits: Limits{MaxLength: 4096}})
+		_, err := d.ReadCompressedByteArray()
+		var e *ErrLimitExceeded
+		if !errors.As(err, &e) || e.Max != 4096 {
+			t.Fatalf("expected ErrLimitExceeded but got '%v'", err)
+		}
+	})
+}
+
+// TestCompressedByteArrayErrors verifies unsupported algorithms and corrupt
+// data result in errors.
+func TestCompressedByteArrayErrors(t *testing.T) {
+	if err := WriteCompressedByteArray(
+		&bytes.Buffer{},
+		[]byte{1},
+		Compression(9)); err == nil {
+		t.Fatal("expected error")
+	}
+	var b bytes.Buffer
+	if err := WriteByte(&b, 9); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteByteArray(&b, []byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadCompressedByteArray(&b); err == nil {
+		t.Fatal("expected error")
+	}
+	b.Reset()
+	if err := WriteByte(&b, byte(CompressionGzip)); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteByteArray(&b, []byte{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadCompressedByteArray(&b); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -16,7 +16,74 @@
 
 package common
 
-import "time"
+import "math"
 
 // The base year for all dates encoded with the io time methods.
-var IoDateBase = time.Date(2020, time.Month(1), 1, 0, 0, 0, 0, time.UTC)
+//
+// Deprecated: Changing the value has no effect. Use DefaultEpoch or set
+// Options.Epoch to encode dates from a different base time.
+var IoDateBase = DefaultEpoch().Base()
+
+// The maximum number of days after the epoch that can be stored by WriteDate.
+const IoDateMaxDays = math.MaxUint16
+
+// The maximum number of minutes after the epoch that can be stored by
+// WriteDateToUInt32.
+const IoDateMaxMinutes = math.MaxUint32
+
+// The maximum number of seconds after the epoch that can be stored by
+// WriteDateSeconds.
+const IoDateMaxSeconds = math.MaxUint32
+
+// The maximum number of hours after the epoch that can be stored by
+// WriteDateHours.
+const IoDateMaxHours = math.MaxUint32
+
+// The maximum number of hours after the epoch that can be stored by
+// WriteDateHours16.
+const IoDateMaxHours16 = math.MaxUint16
+
+// The maximum number of milliseconds after the epoch that can be stored by
+// WriteDateMilliseconds. Limited to a signed 64 bit integer so that
+// conversions do not overflow.
+const IoDateMaxMilliseconds = math.MaxInt64
+
+// The earliest date that can be stored by WriteDate and WriteDateToUInt32 with
+// the default epoch.
+//
+// Deprecated: Changing the value has no effect. Use DefaultEpoch().Base().
+var IoDateMin = DefaultEpoch().Base()
+
+// The latest date that can be stored by WriteDate with the default epoch.
+//
+// Deprecated: Changing the value has no effect. Use DefaultEpoch().MaxDate().
+var IoDateMax = DefaultEpoch().MaxDate()
+
+// The latest date that can be stored by WriteDateToUInt32 with the default
+// epoch.
+//
+// Deprecated: Changing the value has no effect. Use
+// DefaultEpoch().MaxDateMinutes().
+var IoDateMinutesMax = DefaultEpoch().MaxDateMinutes()
+
+// The first byte of a header written by WriteHeader. Used to identify payloads
+// that start with a header.
+const IoHeaderMagic byte = 0x53
+
+// The number of bytes written by WriteHeader.
+const IoHeaderLength = 3
+
+// The maximum number of bytes a compressed byte array can decompress to if
+// Limits.MaxDecompressed is not set.
+const IoMaxDecompressedLength = 16 << 20
+
+// The maximum number of characters returned by EncodeToURL and EncodeToBase32
+// and accepted by DecodeFromURL and DecodeFromBase32. Browsers and servers
+// commonly limit URLs to around 2,000 characters so longer values would not
+// survive a redirect or query string. The rest of the URL must also fit.
+const IoMaxURLLength = 2048
+
+// The maximum number of characters returned by EncodeToCookie and accepted by
+// DecodeFromCookie. Browsers limit the name and value of a cookie to 4,096
+// bytes so the cookie name must also fit.
+const IoMaxCookieLength = 4096
@@ -0,0 +1,751 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// delimReader is implemented by readers that can read single bytes and read up
+// to a delimiter. Both bytes.Buffer and bufio.Reader satisfy the interface.
+type delimReader interface {
+	io.Reader
+	io.ByteReader
+	ReadBytes(delim byte) ([]byte, error)
+}
+
+// Decoder reads values from an io.Reader that were written using the binary
+// format of the Write* functions in io.go or an Encoder.
+type Decoder struct {
+	r     delimReader   // source of the encoded bytes
+	b     *bytes.Buffer // source if a bytes.Buffer, used to avoid copying
+	o     Options       // options controlling the format and limits
+	n     int64         // number of bytes read so far
+	depth int           // current nesting depth of arrays
+}
+
+// maxPreallocate is the largest number of array elements that will be
+// allocated before the elements are read when the number of bytes remaining is
+// not known.
+const maxPreallocate = 1024
+
+// NewDecoder returns a new decoder that reads from r using the fixed width
+// format. If r does not support reading up to a delimiter it is wrapped in a
+// bufio.Reader and the decoder may read more bytes from r than are needed for
+// the values decoded.
+func NewDecoder(r io.Reader) *Decoder {
+	return NewDecoderWithOptions(r, Options{})
+}
+
+// NewDecoderWithOptions returns a new decoder that reads from r using the
+// format specified by the options. See NewDecoder for details of buffering.
+func NewDecoderWithOptions(r io.Reader, o Options) *Decoder {
+	d := &Decoder{o: o}
+	d.init(r)
+	return d
+}
+
+// init sets the source of the decoder. Kept separate from
+// NewDecoderWithOptions so that the constructor can be inlined and a decoder
+// used only within a function, such as those in io.go, isn't allocated on the
+// heap.
+func (d *Decoder) init(r io.Reader) {
+	if b, ok := r.(*bytes.Buffer); ok {
+		d.b = b
+		d.r = b
+	} else if dr, ok := r.(delimReader); ok {
+		d.r = dr
+	} else {
+		d.r = bufio.NewReader(r)
+	}
+}
+
+// ReadStrings into one dimensional array of strings.
+func (d *Decoder) ReadStrings() ([]string, error) {
+	return readSlice(d, (*Decoder).ReadString)
+}
+
+// ReadByteArrayArray from two dimensional array of bytes. The arrays reference
+// the source's storage in the same way as ReadByteArray.
+func (d *Decoder) ReadByteArrayArray() ([][]byte, error) {
+	return readSlice(d, (*Decoder).ReadByteArray)
+}
+
+// ReadStringMap reads a map written by WriteStringMap. Returns an error if a
+// key appears more than once.
+func (d *Decoder) ReadStringMap() (map[string]string, error) {
+	c, err := d.readCount()
+	if err != nil {
+		return nil, err
+	}
+	err = d.enter()
+	if err != nil {
+		return nil, err
+	}
+	defer d.leave()
+	m := make(map[string]string, d.capacity(c))
+	var p string
+	for i := 0; i < c; i++ {
+		k, err := d.ReadString()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := m[k]; ok {
+			return nil, fmt.Errorf("string map key '%s' duplicated", k)
+		}
+		if d.o.Canonical && i > 0 && k < p {
+			return nil, &ErrNotCanonical{
+				Reason: fmt.Sprintf("string map key '%s' after '%s'", k, p)}
+		}
+		p = k
+		m[k], err = d.ReadString()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// ReadUint32s reads integers written by WriteUint32s.
+func (d *Decoder) ReadUint32s() ([]uint32, error) {
+	return readSlice(d, (*Decoder).ReadUint32)
+}
+
+// ReadCount reads the number of elements in an array written by WriteCount.
+// Returns ErrLimitExceeded if the count exceeds the MaxCount limit.
+func (d *Decoder) ReadCount() (int, error) {
+	return d.readCount()
+}
+
+// ReadSliceCount reads the number of elements in an array written by
+// WriteCount before reading the elements into a slice. Returns the count and
+// the capacity to allocate for the slice, which is limited so that hostile
+// counts can't cause large allocations. The elements are nested so the MaxDepth
+// limit is checked and, if no error is returned, Leave must be called after the
+// elements are read. Used by code generated by swangen.
+func (d *Decoder) ReadSliceCount() (int, int, error) {
+	c, err := d.readCount()
+	if err != nil {
+		return 0, 0, err
+	}
+	err = d.enter()
+	if err != nil {
+		return 0, 0, err
+	}
+	return c, d.capacity(c), nil
+}
+
+// Enter is called before reading the fields of a nested struct. Returns
+// ErrLimitExceeded if the MaxDepth limit is exceeded. If no error is returned
+// Leave must be called after the fields are read. Used by code generated by
+// swangen.
+func (d *Decoder) Enter() error {
+	return d.enter()
+}
+
+// Leave is called after reading the elements of a slice following
+// ReadSliceCount, or the fields of a nested struct following Enter.
+func (d *Decoder) Leave() {
+	d.leave()
+}
+
+// ReadFloat32 from the reader.
+func (d *Decoder) ReadFloat32() (float32, error) {
+	f, err := d.ReadUint32()
+	if err != nil {
+		return 0, err
+	}
+	return math.Float32frombits(f), nil
+}
+
+// ReadFloat64 from the reader.
+func (d *Decoder) ReadFloat64() (float64, error) {
+	f, err := d.ReadUint64()
+	if err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(f), nil
+}
+
+// ReadTime from the next binary object. Returns an error if the object is not
+// a valid encoding of a time.
+func (d *Decoder) ReadTime() (time.Time, error) {
+	var t time.Time
+	v, err := d.ReadByteArray()
+	if err != nil {
+		return t, err
+	}
+	err = t.GobDecode(v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time: %w", err)
+	}
+	if d.o.Canonical && t.Location() != time.UTC {
+		return time.Time{}, &ErrNotCanonical{Reason: "time not UTC"}
+	}
+	return t, nil
+}
+
+// ReadTimeCompact reads a time written by WriteTimeCompact. If the zone offset
+// is zero the location is UTC, otherwise it is a fixed zone with the offset.
+func (d *Decoder) ReadTimeCompact() (time.Time, error) {
+	s, err := d.ReadInt64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	n, err := d.ReadUint32()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if n >= uint32(time.Second) {
+		return time.Time{}, fmt.Errorf("nanoseconds '%d' invalid", n)
+	}
+	o, err := d.ReadInt16()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if d.o.Canonical && o != 0 {
+		return time.Time{}, &ErrNotCanonical{Reason: "time not UTC"}
+	}
+	t := time.Unix(s, int64(n))
+	if o == 0 {
+		return t.UTC(), nil
+	}
+	return t.In(time.FixedZone("", int(o)*60)), nil
+}
+
+// ReadDate reads the date from the unsigned 16 bit integer, or unsigned varint
+// if the Varint option is set, and then determines the date by adding this to
+// the epoch.
+func (d *Decoder) ReadDate() (time.Time, error) {
+	i, err := d.readUnits("days", 2, IoDateMaxDays)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return d.o.Epoch.GetDateFromDays(uint16(i)), nil
+}
+
+// ReadDateSeconds reads a date written by WriteDateSeconds.
+func (d *Decoder) ReadDateSeconds() (time.Time, error) {
+	i, err := d.readUnits("seconds", 4, IoDateMaxSeconds)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return d.o.Epoch.GetDateFromSeconds(uint32(i)), nil
+}
+
+// ReadDateHours reads a date written by WriteDateHours.
+func (d *Decoder) ReadDateHours() (time.Time, error) {
+	i, err := d.readUnits("hours", 4, IoDateMaxHours)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return d.o.Epoch.GetDateFromHours(uint32(i)), nil
+}
+
+// ReadDateHours16 reads a date written by WriteDateHours16.
+func (d *Decoder) ReadDateHours16() (time.Time, error) {
+	i, err := d.readUnits("hours", 2, IoDateMaxHours16)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return d.o.Epoch.GetDateFromHours(uint32(i)), nil
+}
+
+// ReadDateMilliseconds reads a date written by WriteDateMilliseconds.
+func (d *Decoder) ReadDateMilliseconds() (time.Time, error) {
+	i, err := d.readUnits("milliseconds", 8, IoDateMaxMilliseconds)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return d.o.Epoch.GetDateFromMilliseconds(i), nil
+}
+
+// ReadMarshaller reads the content into the unmarshaler instance.
+func (d *Decoder) ReadMarshaller(m encoding.BinaryUnmarshaler) error {
+	v, err := d.ReadByteArray()
+	if err != nil {
+		return err
+	}
+	err = d.enter()
+	if err != nil {
+		return err
+	}
+	defer d.leave()
+	return m.UnmarshalBinary(v)
+}
+
+// ReadString reads a null (zero) terminated string, or a string prefixed with
+// its length as an unsigned varint if the Varint option is set. Returns io.EOF
+// if there are no bytes remaining and ErrTruncated if the terminator or some
+// of the bytes are missing. Returns ErrInvalidUTF8 if the ValidateUTF8 option
+// is set and the string is not valid UTF-8.
+func (d *Decoder) ReadString() (string, error) {
+	if d.o.Varint {
+		return d.ReadStringL()
+	}
+	s, err := d.readTerminated()
+	if err == nil {
+		return d.validateString(s[0 : len(s)-1])
+	}
+	if err == io.EOF && len(s) > 0 {
+		return "", &ErrTruncated{
+			Field:     "string",
+			Expected:  int64(len(s) + 1),
+			Available: int64(len(s))}
+	}
+	return "", err
+}
+
+// ReadStringL reads a string written by WriteStringL. Returns ErrTruncated if
+// fewer bytes remain than the length and ErrInvalidUTF8 if the ValidateUTF8
+// option is set and the string is not valid UTF-8.
+func (d *Decoder) ReadStringL() (string, error) {
+	l, err := d.readLength(d.o.Limits.stringLength())
+	if err != nil {
+		return "", err
+	}
+	s, err := d.read("string", l)
+	if err != nil {
+		return "", err
+	}
+	return d.validateString(s)
+}
+
+// validateString returns the bytes as a string or ErrInvalidUTF8 if the
+// ValidateUTF8 option is set and the bytes are not valid UTF-8.
+func (d *Decoder) validateString(s []byte) (string, error) {
+	if d.o.ValidateUTF8 {
+		if i := invalidUTF8(string(s)); i >= 0 {
+			return "", &ErrInvalidUTF8{Index: i}
+		}
+	}
+	return string(s), nil
+}
+
+// ReadByteArray reads the first 4 bytes as an unsigned 32 bit integer, or an
+// unsigned varint if the Varint option is set, to determine the length of the
+// byte array contained in the following bytes. Returns ErrTruncated if fewer
+// bytes remain than the length.
+//
+// ReadByteArray does not copy. If the source is a bytes.Buffer the slice
+// returned references the buffer's storage and is only valid until the buffer
+// is next modified, for example by Write, Reset or Truncate. Use
+// ReadByteArrayCopy if the slice is retained after the buffer is reused.
+func (d *Decoder) ReadByteArray() ([]byte, error) {
+	l, err := d.readLength("MaxLength", d.o.Limits.MaxLength)
+	if err != nil {
+		return nil, err
+	}
+	return d.read("byte array", l)
+}
+
+// ReadByteArrayCopy is the same as ReadByteArray but the slice returned never
+// references the source's storage and is safe to retain.
+func (d *Decoder) ReadByteArrayCopy() ([]byte, error) {
+	v, err := d.ReadByteArray()
+	if err != nil {
+		return nil, err
+	}
+	return d.copy(v), nil
+}
+
+// ReadByteArrayNoLength reads the number of bytes specified into a byte array.
+// Returns ErrTruncated if fewer bytes remain and an error if the length is
+// negative. Does not copy in the same way as ReadByteArray.
+func (d *Decoder) ReadByteArrayNoLength(l int) ([]byte, error) {
+	if l < 0 {
+		return nil, fmt.Errorf("length '%d' negative", l)
+	}
+	return d.read("byte array", l)
+}
+
+// ReadByteArrayNoLengthCopy is the same as ReadByteArrayNoLength but the slice
+// returned never references the source's storage and is safe to retain.
+func (d *Decoder) ReadByteArrayNoLengthCopy(l int) ([]byte, error) {
+	v, err := d.ReadByteArrayNoLength(l)
+	if err != nil {
+		return nil, err
+	}
+	return d.copy(v), nil
+}
+
+// ReadDateFromUInt32 reads the date where the date is stored as the number of
+// minutes as an unsigned 32 bit integer, or an unsigned varint if the Varint
+// option is set, that have elapsed since the epoch.
+func (d *Decoder) ReadDateFromUInt32() (time.Time, error) {
+	i, err := d.readUnits("minutes", 4, IoDateMaxMinutes)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return d.o.Epoch.GetDateFromMinutes(uint32(i)), nil
+}
+
+// ReadByte reads the next byte.
+func (d *Decoder) ReadByte() (byte, error) {
+	v, err := d.read("byte", 1)
+	if err != nil {
+		return 0, err
+	}
+	return v[0], nil
+}
+
+// ReadBool reads the next byte as a bool and returns the value.
+func (d *Decoder) ReadBool() (bool, error) {
+	v, err := d.read("bool", 1)
+	if err != nil {
+		return false, err
+	}
+	if d.o.Canonical && v[0] > 1 {
+		return false, &ErrNotCanonical{
+			Reason: fmt.Sprintf("bool '%d' not 0 or 1", v[0])}
+	}
+	return v[0] != 0, nil
+}
+
+// ReadUint32 reads an unsigned 32 bit integer stored in little endian format.
+func (d *Decoder) ReadUint32() (uint32, error) {
+	v, err := d.read("Uint32", 4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(v), nil
+}
+
+// ReadUint16 reads an unsigned 16 bit integer stored in little endian format.
+func (d *Decoder) ReadUint16() (uint16, error) {
+	v, err := d.read("Uint16", 2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(v), nil
+}
+
+// ReadUint64 reads an unsigned 64 bit integer stored in little endian format.
+func (d *Decoder) ReadUint64() (uint64, error) {
+	v, err := d.read("Uint64", 8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(v), nil
+}
+
+// ReadInt16 reads a signed 16 bit integer stored in little endian two's
+// complement format.
+func (d *Decoder) ReadInt16() (int16, error) {
+	i, err := d.ReadUint16()
+	return int16(i), err
+}
+
+// ReadInt32 reads a signed 32 bit integer stored in little endian two's
+// complement format.
+func (d *Decoder) ReadInt32() (int32, error) {
+	i, err := d.ReadUint32()
+	return int32(i), err
+}
+
+// ReadInt64 reads a signed 64 bit integer stored in little endian two's
+// complement format.
+func (d *Decoder) ReadInt64() (int64, error) {
+	i, err := d.ReadUint64()
+	return int64(i), err
+}
+
+// ReadUvarint reads an unsigned integer written by WriteUvarint.
+func (d *Decoder) ReadUvarint() (uint64, error) {
+	var v uint64
+	var s uint
+	for i := 0; i < binary.MaxVarintLen64; i++ {
+		c, err := d.readByte()
+		if err != nil {
+			if i > 0 && err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		if c < 0x80 {
+			if i == binary.MaxVarintLen64-1 && c > 1 {
+				return 0, errVarintOverflow
+			}
+			v |= uint64(c) << s
+			if d.o.Canonical && c == 0 && i > 0 {
+				return 0, &ErrNotCanonical{
+					Reason: fmt.Sprintf(
+						"varint '%d' uses '%d' bytes not '%d'",
+						v,
+						i+1,
+						uvarintLength(v))}
+			}
+			return v, nil
+		}
+		v |= uint64(c&0x7f) << s
+		s += 7
+	}
+	return 0, errVarintOverflow
+}
+
+// errVarintOverflow is returned by ReadUvarint if the varint doesn't fit in
+// 64 bits.
+var errVarintOverflow = errors.New("varint overflows a 64-bit integer")
+
+// uvarintLength returns the number of bytes WriteUvarint uses for v.
+func uvarintLength(v uint64) int {
+	var b [binary.MaxVarintLen64]byte
+	return binary.PutUvarint(b[:], v)
+}
+
+// readByte reads the next byte counting it against the decoder's limits.
+// Used rather than an io.ByteReader wrapping the decoder so that the decoder
+// doesn't escape to the heap.
+func (d *Decoder) readByte() (byte, error) {
+	err := d.count(1)
+	if err != nil {
+		return 0, err
+	}
+	return d.r.ReadByte()
+}
+
+// readUnits reads the number of units since the epoch stored as an unsigned
+// integer of the size in bytes provided, or as an unsigned varint if the Varint
+// option is set. Returns an error if the value exceeds the maximum m. f is the
+// name of the units.
+func (d *Decoder) readUnits(f string, size int, m uint64) (uint64, error) {
+	var i uint64
+	var err error
+	if d.o.Varint {
+		i, err = d.ReadUvarint()
+	} else {
+		switch size {
+		case 2:
+			var v uint16
+			v, err = d.ReadUint16()
+			i = uint64(v)
+		case 4:
+			var v uint32
+			v, err = d.ReadUint32()
+			i = uint64(v)
+		default:
+			i, err = d.ReadUint64()
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	if i > m {
+		return 0, fmt.Errorf("%s '%d' exceeds '%d'", f, i, m)
+	}
+	return i, nil
+}
+
+// readLength reads the length of a byte array or string. Returns
+// ErrLimitExceeded if the length exceeds the limit m with the name provided.
+func (d *Decoder) readLength(name string, m int) (int, error) {
+	var l uint64
+	if d.o.Varint {
+		v, err := d.ReadUvarint()
+		if err != nil {
+			return 0, err
+		}
+		if v > math.MaxUint32 {
+			return 0, fmt.Errorf("length '%d' too large", v)
+		}
+		l = v
+	} else {
+		v, err := d.ReadUint32()
+		if err != nil {
+			return 0, err
+		}
+		l = uint64(v)
+	}
+	if m > 0 && l > uint64(m) {
+		return 0, &ErrLimitExceeded{
+			Limit: name,
+			Value: int64(l),
+			Max:   int64(m)}
+	}
+	return int(l), nil
+}
+
+// readCount reads the number of elements in an array. Returns
+// ErrLimitExceeded if the count exceeds the MaxCount limit.
+func (d *Decoder) readCount() (int, error) {
+	var c uint64
+	if d.o.Varint {
+		v, err := d.ReadUvarint()
+		if err != nil {
+			return 0, err
+		}
+		if v > math.MaxUint32 {
+			return 0, fmt.Errorf("count '%d' too large", v)
+		}
+		c = v
+	} else {
+		v, err := d.ReadUint16()
+		if err != nil {
+			return 0, err
+		}
+		c = uint64(v)
+	}
+	if m := d.o.Limits.MaxCount; m > 0 && c > uint64(m) {
+		return 0, &ErrLimitExceeded{
+			Limit: "MaxCount",
+			Value: int64(c),
+			Max:   int64(m)}
+	}
+	return int(c), nil
+}
+
+// capacity returns the number of elements to allocate for an array of c
+// elements. Every element occupies at least one byte so no more elements are
+// allocated than there are bytes remaining. If the bytes remaining are not
+// known no more than maxPreallocate elements are allocated.
+func (d *Decoder) capacity(c int) int {
+	m := maxPreallocate
+	if d.b != nil {
+		m = d.b.Len()
+	}
+	if c < m {
+		return c
+	}
+	return m
+}
+
+// enter is called before reading a nested value. Returns ErrLimitExceeded if
+// the MaxDepth limit is exceeded. Must be paired with a call to leave if no
+// error is returned.
+func (d *Decoder) enter() error {
+	if m := d.o.Limits.MaxDepth; m > 0 && d.depth >= m {
+		return &ErrLimitExceeded{
+			Limit: "MaxDepth",
+			Value: int64(d.depth + 1),
+			Max:   int64(m)}
+	}
+	d.depth++
+	return nil
+}
+
+// leave is called after reading a nested value.
+func (d *Decoder) leave() {
+	d.depth--
+}
+
+// count records that n more bytes are being read. Returns ErrLimitExceeded if
+// this would exceed the MaxBytes limit.
+func (d *Decoder) count(n int) error {
+	if m := d.o.Limits.MaxBytes; m > 0 && d.n+int64(n) > int64(m) {
+		return &ErrLimitExceeded{
+			Limit: "MaxBytes",
+			Value: d.n + int64(n),
+			Max:   int64(m)}
+	}
+	d.n += int64(n)
+	return nil
+}
+
+// readTerminated reads up to and including the next null terminator. If a
+// limit applies the bytes are read one at a time so that reading stops as soon
+// as the limit is exceeded.
+func (d *Decoder) readTerminated() ([]byte, error) {
+	name, m := d.o.Limits.stringLength()
+	if m == 0 && d.o.Limits.MaxBytes == 0 {
+		s, err := d.r.ReadBytes(0)
+		d.n += int64(len(s))
+		return s, err
+	}
+	var s []byte
+	for {
+		err := d.count(1)
+		if err != nil {
+			return nil, err
+		}
+		c, err := d.r.ReadByte()
+		if err != nil {
+			return s, err
+		}
+		s = append(s, c)
+		if c == 0 {
+			return s, nil
+		}
+		if m > 0 && len(s) > m {
+			return nil, &ErrLimitExceeded{
+				Limit: name,
+				Value: int64(len(s)),
+				Max:   int64(m)}
+		}
+	}
+}
+
+// read returns the next n bytes from the source or ErrTruncated if fewer than
+// n bytes remain. f is the type of value being read.
+func (d *Decoder) read(f string, n int) ([]byte, error) {
+	v, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	if len(v) != n {
+		return nil, &ErrTruncated{
+			Field:     f,
+			Expected:  int64(n),
+			Available: int64(len(v))}
+	}
+	return v, nil
+}
+
+// copy returns v if it was read from a reader other than a bytes.Buffer and
+// is therefore already a new slice, otherwise a copy of v.
+func (d *Decoder) copy(v []byte) []byte {
+	if d.b == nil {
+		return v
+	}
+	return append(make([]byte, 0, len(v)), v...)
+}
+
+// next returns the next n bytes from the source, or fewer if the source is
+// exhausted. An error is returned if the MaxBytes limit would be exceeded or
+// the source fails for a reason other than reaching the end. Memory is only
+// allocated for large values as the bytes are read so that a hostile length
+// can't cause a large allocation.
+func (d *Decoder) next(n int) ([]byte, error) {
+	err := d.count(n)
+	if err != nil {
+		return nil, err
+	}
+	if d.b != nil {
+		return d.b.Next(n), nil
+	}
+	if n > maxPreallocate {
+		var b bytes.Buffer
+		_, err = io.CopyN(&b, d.r, int64(n))
+		if err == io.EOF {
+			err = nil
+		}
+		return b.Bytes(), err
+	}
+	v := make([]byte, n)
+	c, err := io.ReadFull(d.r, v)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	return v[:c], err
+}
@@ -0,0 +1,450 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// testCodecValues contains the values written and read by the codec tests.
+type testCodecValues struct {
+	s    string
+	ss   []string
+	ba   []byte
+	baa  [][]byte
+	u16  uint16
+	u32  uint32
+	f32  float32
+	b    byte
+	bool bool
+	date time.Time
+	min  time.Time
+	time time.Time
+}
+
+func newTestCodecValues() *testCodecValues {
+	return &testCodecValues{
+		s:    "Hello World",
+		ss:   []string{"A", "", "BC"},
+		ba:   []byte{1, 2, 3, 4},
+		baa:  [][]byte{{1}, {}, {2, 3}},
+		u16:  0xABCD,
+		u32:  0x01234567,
+		f32:  3.25,
+		b:    0x7F,
+		bool: true,
+		date: time.Date(2022, time.March, 4, 0, 0, 0, 0, time.UTC),
+		min:  time.Date(2022, time.March, 4, 5, 6, 0, 0, time.UTC),
+		time: time.Date(2022, time.March, 4, 5, 6, 7, 8, time.UTC)}
+}
+
+// TestEncoderMatchesBuffer verifies the encoder produces the same bytes as the
+// bytes.Buffer functions when writing to a plain io.Writer.
+func TestEncoderMatchesBuffer(t *testing.T) {
+	v := newTestCodecValues()
+	var a bytes.Buffer
+	writeTestCodecValues(t, &a, v)
+	var s strings.Builder
+	encodeTestCodecValues(t, NewEncoder(&s), v)
+	if !bytes.Equal(a.Bytes(), []byte(s.String())) {
+		t.Fatal("encoder and buffer functions produced different bytes")
+	}
+}
+
+// TestDecoderStream verifies values can be decoded from a reader that only
+// returns a single byte for each read and does not implement ReadBytes.
+func TestDecoderStream(t *testing.T) {
+	v := newTestCodecValues()
+	var b bytes.Buffer
+	writeTestCodecValues(t, &b, v)
+	decodeTestCodecValues(t, NewDecoder(iotest.OneByteReader(&b)), v)
+}
+
+// TestVarint verifies the varint format round trips, uses fewer bytes than the
+// fixed width format for small values, and reads from a stream.
+func TestVarint(t *testing.T) {
+	o := Options{Varint: true}
+	v := newTestCodecValues()
+	var f bytes.Buffer
+	writeTestCodecValues(t, &f, v)
+	var b bytes.Buffer
+	encodeTestCodecValues(t, NewEncoderWithOptions(&b, o), v)
+	if b.Len() >= f.Len() {
+		t.Fatalf("varint '%d' bytes not less than fixed '%d'", b.Len(), f.Len())
+	}
+	t.Run("buffer", func(t *testing.T) {
+		c := bytes.NewBuffer(b.Bytes())
+		decodeTestCodecValues(t, NewDecoderWithOptions(c, o), v)
+	})
+	t.Run("stream", func(t *testing.T) {
+		c := iotest.OneByteReader(bytes.NewBuffer(b.Bytes()))
+		decodeTestCodecValues(t, NewDecoderWithOptions(c, o), v)
+	})
+	t.Run("uvarint", func(t *testing.T) {
+		for _, i := range []uint64{0, 127, 128, 1 << 32, 1<<64 - 1} {
+			var c bytes.Buffer
+			if err := WriteUvarint(&c, i); err != nil {
+				t.Fatal(err)
+			}
+			r, err := ReadUvarint(&c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r != i {
+				t.Fatalf("'%d' != '%d'", r, i)
+			}
+			if c.Len() != 0 {
+				t.Fatal("unread bytes")
+			}
+		}
+	})
+}
+
+func encodeTestCodecValues(t testing.TB, e *Encoder, v *testCodecValues) {
+	if err := e.WriteString(v.s); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteStrings(v.ss); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteByteArray(v.ba); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteByteArrayArray(v.baa); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteUint16(v.u16); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteUint32(v.u32); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteFloat32(v.f32); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteByte(v.b); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteBool(v.bool); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteDate(v.date); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteDateToUInt32(v.min); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteTime(v.time); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func decodeTestCodecValues(t *testing.T, d *Decoder, v *testCodecValues) {
+	s, err := d.ReadString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != v.s {
+		t.Fatal("string")
+	}
+	ss, err := d.ReadStrings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ss, v.ss) {
+		t.Fatal("strings")
+	}
+	ba, err := d.ReadByteArray()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ba, v.ba) {
+		t.Fatal("byte array")
+	}
+	baa, err := d.ReadByteArrayArray()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(baa, v.baa) {
+		t.Fatal("byte array array")
+	}
+	u16, err := d.ReadUint16()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u16 != v.u16 {
+		t.Fatal("uint16")
+	}
+	u32, err := d.ReadUint32()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u32 != v.u32 {
+		t.Fatal("uint32")
+	}
+	f32, err := d.ReadFloat32()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f32 != v.f32 {
+		t.Fatal("float32")
+	}
+	c, err := d.ReadByte()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != v.b {
+		t.Fatal("byte")
+	}
+	bl, err := d.ReadBool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bl != v.bool {
+		t.Fatal("bool")
+	}
+	date, err := d.ReadDate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !date.Equal(v.date) {
+		t.Fatal("date")
+	}
+	min, err := d.ReadDateFromUInt32()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !min.Equal(v.min) {
+		t.Fatal("date from uint32")
+	}
+	tm, err := d.ReadTime()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tm.Equal(v.time) {
+		t.Fatal("time")
+	}
+	if _, err = d.ReadByte(); err == nil {
+		t.Fatal("expected error at end of stream")
+	}
+}
+
+func writeTestCodecValues(t *testing.T, b *bytes.Buffer, v *testCodecValues) {
+	if err := WriteString(b, v.s); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteStrings(b, v.ss); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteByteArray(b, v.ba); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteByteArrayArray(b, v.baa); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteUint16(b, v.u16); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteUint32(b, v.u32); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFloat32(b, v.f32); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteByte(b, v.b); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteBool(b, v.bool); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteDate(b, v.date); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteDateToUInt32(b, v.min); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteTime(b, v.time); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestDecoderLimits verifies that each of the limits results in
+// ErrLimitExceeded when exceeded.
+func TestDecoderLimits(t *testing.T) {
+	var b bytes.Buffer
+	writeTestCodecValues(t, &b, newTestCodecValues())
+	t.Run("max bytes", func(t *testing.T) {
+		d := NewDecoderWithOptions(
+			bytes.NewBuffer(b.Bytes()),
+			Options{Limits: Limits{MaxBytes: 19}})
+		if _, err := d.ReadString(); err != nil {
+			t.Fatal(err)
+		}
+		_, err := d.ReadStrings()
+		testLimitExceeded(t, err, "MaxBytes")
+	})
+	t.Run("max count", func(t *testing.T) {
+		d := NewDecoderWithOptions(
+			bytes.NewBuffer(b.Bytes()),
+			Options{Limits: Limits{MaxCount: 2}})
+		if _, err := d.ReadString(); err != nil {
+			t.Fatal(err)
+		}
+		_, err := d.ReadStrings()
+		testLimitExceeded(t, err, "MaxCount")
+	})
+	t.Run("max length", func(t *testing.T) {
+		var c bytes.Buffer
+		if err := WriteByteArray(&c, make([]byte, 10)); err != nil {
+			t.Fatal(err)
+		}
+		d := NewDecoderWithOptions(&c, Options{Limits: Limits{MaxLength: 9}})
+		_, err := d.ReadByteArray()
+		testLimitExceeded(t, err, "MaxLength")
+	})
+	t.Run("max string length", func(t *testing.T) {
+		for _, o := range []Options{
+			{Limits: Limits{MaxLength: 20, MaxStringLength: 4}},
+			{Varint: true, Limits: Limits{MaxStringLength: 4}}} {
+			var c bytes.Buffer
+			e := NewEncoderWithOptions(&c, Options{Varint: o.Varint})
+			if err := e.WriteString("Hello World"); err != nil {
+				t.Fatal(err)
+			}
+			_, err := NewDecoderWithOptions(&c, o).ReadString()
+			testLimitExceeded(t, err, "MaxStringLength")
+		}
+	})
+	t.Run("max depth", func(t *testing.T) {
+		d := NewDecoderWithOptions(&b, Options{Limits: Limits{MaxDepth: 1}})
+		if err := d.enter(); err != nil {
+			t.Fatal(err)
+		}
+		testLimitExceeded(t, d.enter(), "MaxDepth")
+		d.leave()
+		if err := d.enter(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("max depth struct", func(t *testing.T) {
+		type s struct {
+			V []string `swan:"1"`
+		}
+		m, err := Marshal(s{V: []string{"A"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		o := Options{Limits: Limits{MaxDepth: 1}}
+		var v s
+		err = NewDecoderWithOptions(bytes.NewBuffer(m), o).Decode(&v)
+		testLimitExceeded(t, err, "MaxDepth")
+		o.Limits.MaxDepth = 2
+		err = NewDecoderWithOptions(bytes.NewBuffer(m), o).Decode(&v)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("hostile length", func(t *testing.T) {
+		c := []byte{0xFF, 0xFF, 0xFF, 0xFF, 1, 2, 3}
+		d := NewDecoder(iotest.OneByteReader(bytes.NewBuffer(c)))
+		_, err := d.ReadByteArray()
+		testTruncated(t, err, 0xFFFFFFFF, 3)
+	})
+	t.Run("hostile count", func(t *testing.T) {
+		var c bytes.Buffer
+		if err := WriteUvarint(&c, 0xFFFFFFFF); err != nil {
+			t.Fatal(err)
+		}
+		d := NewDecoderWithOptions(&c, Options{Varint: true})
+		if _, err := d.ReadStrings(); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func testLimitExceeded(t *testing.T, err error, limit string) {
+	var e *ErrLimitExceeded
+	if !errors.As(err, &e) {
+		t.Fatalf("expected ErrLimitExceeded but got '%v'", err)
+	}
+	if e.Limit != limit {
+		t.Fatalf("expected limit '%s' but got '%s'", limit, e.Limit)
+	}
+	if e.Value <= e.Max {
+		t.Fatalf("value '%d' does not exceed '%d'", e.Value, e.Max)
+	}
+}
+
+// TestDecoderAllocations verifies the package Read* functions don't allocate
+// when reading from a bytes.Buffer.
+func TestDecoderAllocations(t *testing.T) {
+	var w bytes.Buffer
+	e := NewEncoder(&w)
+	for _, err := range []error{
+		e.WriteByteArray([]byte{1, 2, 3}),
+		e.WriteByteArrayNoLength([]byte{4, 5}),
+		e.WriteByte(1),
+		e.WriteBool(true),
+		e.WriteUint16(1),
+		e.WriteUint32(1),
+		e.WriteUint64(1),
+		e.WriteInt16(-1),
+		e.WriteInt32(-1),
+		e.WriteInt64(-1),
+		e.WriteFloat32(1.5),
+		e.WriteFloat64(-1.5),
+		e.WriteUvarint(300),
+		e.WriteDate(DefaultEpoch().Base()),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	data := w.Bytes()
+	var b bytes.Buffer
+	b.Grow(len(data))
+	a := testing.AllocsPerRun(100, func() {
+		b.Reset()
+		b.Write(data)
+		_, _ = ReadByteArray(&b)
+		_, _ = ReadByteArrayNoLength(&b, 2)
+		_, _ = ReadByte(&b)
+		_, _ = ReadBool(&b)
+		_, _ = ReadUint16(&b)
+		_, _ = ReadUint32(&b)
+		_, _ = ReadUint64(&b)
+		_, _ = ReadInt16(&b)
+		_, _ = ReadInt32(&b)
+		_, _ = ReadInt64(&b)
+		_, _ = ReadFloat32(&b)
+		_, _ = ReadFloat64(&b)
+		_, _ = ReadUvarint(&b)
+		_, _ = ReadDate(&b)
+		if b.Len() != 0 {
+			t.Fatalf("'%d' bytes not read", b.Len())
+		}
+	})
+	if a != 0 {
+		t.Fatalf("'%f' allocations", a)
+	}
+}
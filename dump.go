@@ -0,0 +1,115 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// The maximum number of bytes shown in hex for a single field by Dump.
+const dumpMaxHex = 16
+
+// Dump writes each field of the schema read from the data to w with the
+// offset, length, raw bytes and value of the field. If a field can't be read
+// the line is marked with >> and the error, and the bytes remaining from the
+// start of the field are written in hex. Any bytes remaining after the last
+// field are also written. Returns the error that stopped the data being read.
+func Dump(w io.Writer, s Schema, data []byte) error {
+	return DumpWithOptions(w, s, data, Options{})
+}
+
+// DumpWithOptions is the same as Dump but reads the data with the options
+// provided, for example to dump data written in the Varint format.
+func DumpWithOptions(w io.Writer, s Schema, data []byte, o Options) error {
+	t := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(t, "\toffset\tlength\tname\ttype\tbytes\tvalue")
+	b := bytes.NewBuffer(data)
+	d := NewDecoderWithOptions(b, o)
+	p := 0
+	var err error
+	for _, f := range s {
+		var v interface{}
+		v, err = f.read(d)
+		if err != nil {
+			fmt.Fprintf(t, ">>\t%04x\t\t%s\t%s\t\tERROR: %v\n",
+				p,
+				f.Name,
+				f.Type,
+				err)
+			break
+		}
+		n := len(data) - b.Len()
+		fmt.Fprintf(t, "\t%04x\t%d\t%s\t%s\t%s\t%s\n",
+			p,
+			n-p,
+			f.Name,
+			f.Type,
+			dumpHex(data[p:n]),
+			dumpValue(v))
+		p = n
+	}
+	e := t.Flush()
+	if e != nil {
+		return e
+	}
+	if p < len(data) {
+		fmt.Fprintf(w, "%d bytes remain from offset %04x\n", len(data)-p, p)
+		for i := p; i < len(data); i += dumpMaxHex {
+			j := i + dumpMaxHex
+			if j > len(data) {
+				j = len(data)
+			}
+			_, e = fmt.Fprintf(w, "  %04x  % x\n", i, data[i:j])
+			if e != nil {
+				return e
+			}
+		}
+	}
+	return err
+}
+
+// dumpHex returns the bytes as hex truncated to dumpMaxHex bytes.
+func dumpHex(b []byte) string {
+	if len(b) > dumpMaxHex {
+		return fmt.Sprintf("% x ...", b[:dumpMaxHex])
+	}
+	return fmt.Sprintf("% x", b)
+}
+
+// dumpValue returns the value formatted for Dump.
+func dumpValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []string:
+		return fmt.Sprintf("%q", v)
+	case []byte:
+		if len(v) > dumpMaxHex {
+			return fmt.Sprintf("[%x...] (%d bytes)", v[:dumpMaxHex], len(v))
+		}
+		return fmt.Sprintf("[%x]", v)
+	case [][]byte:
+		return fmt.Sprintf("%x", v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
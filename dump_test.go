@@ -0,0 +1,105 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newTestDumpSchema(t *testing.T) Schema {
+	s, err := ParseSchema("name:string,data:bytearray,expires:date,count:uint32")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// writeTestDump writes the fields of the test schema with count written as a
+// uint16 so that the last field can't be read.
+func writeTestDump(t *testing.T, b *bytes.Buffer) {
+	if err := WriteString(b, "Hello"); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteByteArray(b, []byte{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteDate(b, DefaultEpoch().Base().AddDate(0, 0, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteUint16(b, 7); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestDump verifies each field is written with its offset, length, bytes and
+// value, and the field that fails is highlighted with the remaining bytes.
+func TestDump(t *testing.T) {
+	var b, w bytes.Buffer
+	writeTestDump(t, &b)
+	err := Dump(&w, newTestDumpSchema(t), b.Bytes())
+	var e *ErrTruncated
+	if !errors.As(err, &e) {
+		t.Fatalf("expected ErrTruncated but got '%v'", err)
+	}
+	l := strings.Split(w.String(), "\n")
+	for i, c := range [][]string{
+		{"offset", "length", "name", "type", "bytes", "value"},
+		{"0000", "6", "name", "string", "48 65 6c 6c 6f 00", `"Hello"`},
+		{"0006", "7", "data", "bytearray", "03 00 00 00 01 02 03", "[010203]"},
+		{"000d", "2", "expires", "date", "01 00", "2020-01-02T00:00:00Z"},
+		{">>", "000f", "count", "uint32", "ERROR:", "truncated"},
+		{"2 bytes remain from offset 000f"},
+		{"000f", "07 00"},
+	} {
+		for _, f := range c {
+			if !strings.Contains(l[i], f) {
+				t.Fatalf("line '%s' does not contain '%s'", l[i], f)
+			}
+		}
+	}
+}
+
+// TestDumpRemaining verifies bytes after the last field are written and no
+// error is returned if all the fields are read.
+func TestDumpRemaining(t *testing.T) {
+	var b, w bytes.Buffer
+	writeTestDump(t, &b)
+	b.Write([]byte{0, 0, 9})
+	if err := Dump(&w, newTestDumpSchema(t), b.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(w.String(), ">>") {
+		t.Fatal("unexpected error highlighted")
+	}
+	if !strings.Contains(w.String(), "1 bytes remain from offset 0013") {
+		t.Fatalf("remaining bytes not written in '%s'", w.String())
+	}
+	w.Reset()
+	s := newTestDumpSchema(t)[:3]
+	var v bytes.Buffer
+	writeTestDump(t, &v)
+	v.Truncate(v.Len() - 2)
+	if err := Dump(&w, s, v.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(w.String(), "remain") {
+		t.Fatalf("unexpected remaining bytes in '%s'", w.String())
+	}
+}
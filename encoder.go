@@ -0,0 +1,484 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Encoder writes values to an io.Writer using the same binary format as the
+// Write* functions in io.go.
+type Encoder struct {
+	w io.Writer     // destination for the encoded bytes
+	b *bytes.Buffer // destination if a bytes.Buffer, used to avoid allocation
+	o Options       // options controlling the format
+	s []byte        // scratch space for writers other than a bytes.Buffer
+}
+
+// NewEncoder returns a new encoder that writes to w using the fixed width
+// format.
+func NewEncoder(w io.Writer) *Encoder {
+	return NewEncoderWithOptions(w, Options{})
+}
+
+// NewEncoderWithOptions returns a new encoder that writes to w using the
+// format specified by the options.
+func NewEncoderWithOptions(w io.Writer, o Options) *Encoder {
+	e := &Encoder{w: w, o: o}
+	if b, ok := w.(*bytes.Buffer); ok {
+		e.b = b
+	}
+	return e
+}
+
+// WriteStrings one dimensional array of strings. Returns ErrTooLong if the
+// number of strings can't be represented or exceeds the MaxCount limit.
+func (e *Encoder) WriteStrings(v []string) error {
+	return writeSlice(e, "strings", v, (*Encoder).WriteString)
+}
+
+// WriteByteArrayArray two dimensional array of bytes. Returns ErrTooLong if
+// the number of arrays can't be represented or exceeds the MaxCount limit.
+func (e *Encoder) WriteByteArrayArray(v [][]byte) error {
+	return writeSlice(e, "byte array array", v, (*Encoder).WriteByteArray)
+}
+
+// WriteStringMap writes the number of entries followed by each key and value
+// as strings. Keys are written in ascending order so that the same map always
+// produces the same bytes, for example when the bytes are signed. Returns
+// ErrTooLong if the number of entries can't be represented or exceeds the
+// MaxCount limit.
+func (e *Encoder) WriteStringMap(m map[string]string) error {
+	err := e.writeCount("string map", len(m))
+	if err != nil {
+		return err
+	}
+	k := make([]string, 0, len(m))
+	for i := range m {
+		k = append(k, i)
+	}
+	sort.Strings(k)
+	for _, i := range k {
+		err = e.WriteString(i)
+		if err != nil {
+			return err
+		}
+		err = e.WriteString(m[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteUint32s writes the number of integers followed by each integer as an
+// unsigned 32 bit integer. Returns ErrTooLong if the number of integers can't
+// be represented or exceeds the MaxCount limit.
+func (e *Encoder) WriteUint32s(v []uint32) error {
+	return writeSlice(e, "uint32s", v, (*Encoder).WriteUint32)
+}
+
+// WriteCount writes the number of elements in an array in the same way as
+// WriteStrings and WriteByteArrayArray. Used before writing the elements of
+// arrays of other types. Returns ErrTooLong if the count can't be represented
+// or exceeds the MaxCount limit.
+func (e *Encoder) WriteCount(c int) error {
+	return e.writeCount("array", c)
+}
+
+// WriteFloat32 to the writer.
+func (e *Encoder) WriteFloat32(f float32) error {
+	return e.WriteUint32(math.Float32bits(f))
+}
+
+// WriteFloat64 to the writer.
+func (e *Encoder) WriteFloat64(f float64) error {
+	return e.WriteUint64(math.Float64bits(f))
+}
+
+// WriteTime as a binary object. If the Canonical option is set the time is
+// converted to UTC first.
+func (e *Encoder) WriteTime(t time.Time) error {
+	if e.o.Canonical {
+		t = t.UTC()
+	}
+	d, err := t.GobEncode()
+	if err != nil {
+		return err
+	}
+	return e.WriteByteArray(d)
+}
+
+// WriteTimeCompact writes the time as a fixed 14 bytes comprising the seconds
+// since the Unix epoch as a signed 64 bit integer, the nanoseconds within the
+// second as an unsigned 32 bit integer, and the zone offset in minutes as a
+// signed 16 bit integer. The name of the location is not preserved. If the
+// Canonical option is set the time is converted to UTC first. Returns an error
+// if the zone offset is not a whole number of minutes.
+func (e *Encoder) WriteTimeCompact(t time.Time) error {
+	if e.o.Canonical {
+		t = t.UTC()
+	}
+	_, o := t.Zone()
+	if o%60 != 0 {
+		return fmt.Errorf("zone offset '%d' seconds not whole minutes", o)
+	}
+	err := e.WriteInt64(t.Unix())
+	if err != nil {
+		return err
+	}
+	err = e.WriteUint32(uint32(t.Nanosecond()))
+	if err != nil {
+		return err
+	}
+	return e.WriteInt16(int16(o / 60))
+}
+
+// WriteDate writes the date as the number of days since the epoch. Uses an
+// unsigned 16 bit integer, or an unsigned varint if the Varint option is set.
+// Returns ErrDateOutOfRange if the date is before the epoch or after
+// Epoch.MaxDate.
+func (e *Encoder) WriteDate(t time.Time) error {
+	v, err := e.o.Epoch.GetDateInDays(t)
+	if err != nil {
+		return err
+	}
+	return e.writeUnits(uint64(v), 2)
+}
+
+// WriteDateSeconds writes the date as the number of seconds since the epoch.
+// Uses an unsigned 32 bit integer, or an unsigned varint if the Varint option
+// is set. Returns ErrDateOutOfRange if the date is out of range.
+func (e *Encoder) WriteDateSeconds(t time.Time) error {
+	v, err := e.o.Epoch.GetDateInSeconds(t)
+	if err != nil {
+		return err
+	}
+	return e.writeUnits(uint64(v), 4)
+}
+
+// WriteDateHours writes the date as the number of hours since the epoch. Uses
+// an unsigned 32 bit integer, or an unsigned varint if the Varint option is
+// set. Returns ErrDateOutOfRange if the date is out of range.
+func (e *Encoder) WriteDateHours(t time.Time) error {
+	v, err := e.o.Epoch.GetDateInHours(t)
+	if err != nil {
+		return err
+	}
+	return e.writeUnits(uint64(v), 4)
+}
+
+// WriteDateHours16 writes the date as the number of hours since the epoch.
+// Uses an unsigned 16 bit integer, or an unsigned varint if the Varint option
+// is set. Returns ErrDateOutOfRange if the date is out of range.
+func (e *Encoder) WriteDateHours16(t time.Time) error {
+	v, err := e.o.Epoch.GetDateInHours16(t)
+	if err != nil {
+		return err
+	}
+	return e.writeUnits(uint64(v), 2)
+}
+
+// WriteDateMilliseconds writes the date as the number of milliseconds since
+// the epoch. Uses an unsigned 64 bit integer, or an unsigned varint if the
+// Varint option is set. Returns ErrDateOutOfRange if the date is out of range.
+func (e *Encoder) WriteDateMilliseconds(t time.Time) error {
+	v, err := e.o.Epoch.GetDateInMilliseconds(t)
+	if err != nil {
+		return err
+	}
+	return e.writeUnits(v, 8)
+}
+
+// WriteMarshaller writes the result of marshal binary call to the writer.
+func (e *Encoder) WriteMarshaller(m encoding.BinaryMarshaler) error {
+	v, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return e.WriteByteArray(v)
+}
+
+// WriteString writes a null (zero) terminated string to the writer. If the
+// Varint option is set the string is prefixed with its length as an unsigned
+// varint instead in the same way as WriteStringL. Returns ErrEmbeddedNull if a
+// null terminated string contains a zero byte as it could not be read back,
+// ErrInvalidUTF8 if the ValidateUTF8 option is set and the string is not valid
+// UTF-8, and ErrTooLong if the string exceeds the MaxLength or MaxStringLength
+// limits.
+func (e *Encoder) WriteString(s string) error {
+	if e.o.Varint {
+		return e.WriteStringL(s)
+	}
+	err := e.validateString(s)
+	if err != nil {
+		return err
+	}
+	_, l := e.o.Limits.stringLength()
+	m := limit(l, math.MaxInt64)
+	if int64(len(s)) > m {
+		return &ErrTooLong{Field: "string", Length: int64(len(s)), Limit: m}
+	}
+	if i := strings.IndexByte(s, 0); i >= 0 {
+		return &ErrEmbeddedNull{Index: i}
+	}
+	err = e.writeString(s)
+	if err != nil {
+		return err
+	}
+	return e.WriteByte(0)
+}
+
+// WriteStringL writes the length of the string as an unsigned 32 bit integer,
+// or an unsigned varint if the Varint option is set, followed by the bytes of
+// the string. Unlike WriteString the string can contain zero bytes. Returns
+// ErrInvalidUTF8 if the ValidateUTF8 option is set and the string is not valid
+// UTF-8, and ErrTooLong if the string exceeds the MaxLength or MaxStringLength
+// limits.
+func (e *Encoder) WriteStringL(s string) error {
+	err := e.validateString(s)
+	if err != nil {
+		return err
+	}
+	_, l := e.o.Limits.stringLength()
+	err = e.writeLength("string", len(s), l)
+	if err != nil {
+		return err
+	}
+	return e.writeString(s)
+}
+
+// WriteByteArray writes the length of the byte array as an unsigned 32 bit
+// integer, or an unsigned varint if the Varint option is set, followed by the
+// bytes. Returns ErrTooLong if the length can't be represented or exceeds the
+// MaxLength limit.
+func (e *Encoder) WriteByteArray(v []byte) error {
+	err := e.writeLength("byte array", len(v), e.o.Limits.MaxLength)
+	if err != nil {
+		return err
+	}
+	return e.WriteByteArrayNoLength(v)
+}
+
+// WriteByteArrayNoLength writes the byte array without recording the length.
+// Used with fixed length data.
+func (e *Encoder) WriteByteArrayNoLength(v []byte) error {
+	if e.b != nil {
+		e.b.Write(v)
+		return nil
+	}
+	l, err := e.w.Write(v)
+	if err == nil {
+		if l != len(v) {
+			return fmt.Errorf(
+				"mismatched lengths '%d' and '%d'",
+				l,
+				len(v))
+		}
+	}
+	return err
+}
+
+// WriteDateToUInt32 writes the date as an unsigned 32 bit integer representing
+// the number of minutes that have elapsed since the epoch. If the Varint option
+// is set the minutes are written as an unsigned varint. Returns
+// ErrDateOutOfRange if the date is before the epoch or after
+// Epoch.MaxDateMinutes.
+func (e *Encoder) WriteDateToUInt32(t time.Time) error {
+	v, err := e.o.Epoch.GetDateInMinutes(t)
+	if err != nil {
+		return err
+	}
+	return e.writeUnits(uint64(v), 4)
+}
+
+// WriteByte writes the byte provided.
+func (e *Encoder) WriteByte(i byte) error {
+	return e.write([]byte{i})
+}
+
+// WriteBool writes the boolean value as a byte.
+func (e *Encoder) WriteBool(v bool) error {
+	var d byte
+	if v {
+		d = 1
+	}
+	return e.WriteByte(d)
+}
+
+// WriteUint16 writes an unsigned 16 bit integer in little endian format.
+func (e *Encoder) WriteUint16(i uint16) error {
+	var v [2]byte
+	binary.LittleEndian.PutUint16(v[:], i)
+	return e.write(v[:])
+}
+
+// WriteUint32 writes an unsigned 32 bit integer in little endian format.
+func (e *Encoder) WriteUint32(i uint32) error {
+	var v [4]byte
+	binary.LittleEndian.PutUint32(v[:], i)
+	return e.write(v[:])
+}
+
+// WriteUint64 writes an unsigned 64 bit integer in little endian format.
+func (e *Encoder) WriteUint64(i uint64) error {
+	var v [8]byte
+	binary.LittleEndian.PutUint64(v[:], i)
+	return e.write(v[:])
+}
+
+// WriteInt16 writes a signed 16 bit integer in little endian two's complement
+// format.
+func (e *Encoder) WriteInt16(i int16) error {
+	return e.WriteUint16(uint16(i))
+}
+
+// WriteInt32 writes a signed 32 bit integer in little endian two's complement
+// format.
+func (e *Encoder) WriteInt32(i int32) error {
+	return e.WriteUint32(uint32(i))
+}
+
+// WriteInt64 writes a signed 64 bit integer in little endian two's complement
+// format.
+func (e *Encoder) WriteInt64(i int64) error {
+	return e.WriteUint64(uint64(i))
+}
+
+// WriteUvarint writes an unsigned integer using between 1 and 10 bytes where
+// small values use fewer bytes.
+func (e *Encoder) WriteUvarint(i uint64) error {
+	var v [binary.MaxVarintLen64]byte
+	return e.write(v[:binary.PutUvarint(v[:], i)])
+}
+
+// writeString writes the bytes of the string without a length or terminator.
+func (e *Encoder) writeString(s string) error {
+	if e.b != nil {
+		e.b.WriteString(s)
+		return nil
+	}
+	l, err := io.WriteString(e.w, s)
+	if err != nil {
+		return err
+	}
+
+	// Validate the number of bytes written matches the number of bytes in the
+	// string.
+	if l != len(s) {
+		return fmt.Errorf("mismatched lengths '%d' and '%d'", l, len(s))
+	}
+	return nil
+}
+
+// validateString returns ErrInvalidUTF8 if the ValidateUTF8 option is set and
+// the string is not valid UTF-8.
+func (e *Encoder) validateString(s string) error {
+	if e.o.ValidateUTF8 {
+		if i := invalidUTF8(s); i >= 0 {
+			return &ErrInvalidUTF8{Index: i}
+		}
+	}
+	return nil
+}
+
+// invalidUTF8 returns the index of the first byte of s that is not part of a
+// valid UTF-8 sequence, or -1 if s is valid UTF-8.
+func invalidUTF8(s string) int {
+	if utf8.ValidString(s) {
+		return -1
+	}
+	for i := 0; i < len(s); {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && n == 1 {
+			return i
+		}
+		i += n
+	}
+	return -1
+}
+
+// write writes the bytes of a single value without retaining v so that the
+// callers can use stack space for v. A bytes.Buffer is written to directly.
+// Other writers are passed a copy of v in the encoder's scratch space as the
+// compiler can't prove that an io.Writer does not retain v.
+func (e *Encoder) write(v []byte) error {
+	if e.b != nil {
+		e.b.Write(v)
+		return nil
+	}
+	if len(e.s) < len(v) {
+		e.s = make([]byte, len(v)+binary.MaxVarintLen64)
+	}
+	return e.WriteByteArrayNoLength(e.s[:copy(e.s, v)])
+}
+
+// writeUnits writes the number of units since the epoch as an unsigned integer
+// of the size in bytes provided, or as an unsigned varint if the Varint option
+// is set.
+func (e *Encoder) writeUnits(v uint64, size int) error {
+	if e.o.Varint {
+		return e.WriteUvarint(v)
+	}
+	switch size {
+	case 2:
+		return e.WriteUint16(uint16(v))
+	case 4:
+		return e.WriteUint32(uint32(v))
+	}
+	return e.WriteUint64(v)
+}
+
+// writeLength writes the length of a byte array or string. Lengths are limited
+// to an unsigned 32 bit integer in both formats and to the configured limit c.
+func (e *Encoder) writeLength(f string, l int, c int) error {
+	m := limit(c, math.MaxUint32)
+	if int64(l) > m {
+		return &ErrTooLong{Field: f, Length: int64(l), Limit: m}
+	}
+	if e.o.Varint {
+		return e.WriteUvarint(uint64(l))
+	}
+	return e.WriteUint32(uint32(l))
+}
+
+// writeCount writes the number of elements in an array. Counts are limited to
+// an unsigned 16 bit integer in the fixed width format and an unsigned 32 bit
+// integer in the varint format.
+func (e *Encoder) writeCount(f string, c int) error {
+	var m int64 = math.MaxUint16
+	if e.o.Varint {
+		m = math.MaxUint32
+	}
+	m = limit(e.o.Limits.MaxCount, m)
+	if int64(c) > m {
+		return &ErrTooLong{Field: f, Length: int64(c), Limit: m}
+	}
+	if e.o.Varint {
+		return e.WriteUvarint(uint64(c))
+	}
+	return e.WriteUint16(uint16(c))
+}
@@ -0,0 +1,115 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// BenchmarkWriteUint32 measures writing integers with the package functions.
+func BenchmarkWriteUint32(b *testing.B) {
+	var buf bytes.Buffer
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		for j := 0; j < 16; j++ {
+			if err := WriteUint32(&buf, uint32(j)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkEncoder measures writing a record of typical values to a
+// bytes.Buffer with a reused Encoder.
+func BenchmarkEncoder(b *testing.B) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	v := newTestCodecValues()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		encodeTestCodecValues(b, e, v)
+	}
+}
+
+// BenchmarkEncoderWriter measures writing the same record to a writer that is
+// not a bytes.Buffer.
+func BenchmarkEncoderWriter(b *testing.B) {
+	e := NewEncoder(io.Discard)
+	v := newTestCodecValues()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		encodeTestCodecValues(b, e, v)
+	}
+}
+
+// BenchmarkPooledBuffer measures serialising with a buffer from the pool as
+// request handlers do.
+func BenchmarkPooledBuffer(b *testing.B) {
+	v := newTestCodecValues()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := GetBuffer()
+		encodeTestCodecValues(b, NewEncoder(buf), v)
+		PutBuffer(buf)
+	}
+}
+
+// BenchmarkNewBuffer measures serialising with a new buffer each time for
+// comparison with BenchmarkPooledBuffer.
+func BenchmarkNewBuffer(b *testing.B) {
+	v := newTestCodecValues()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var buf bytes.Buffer
+		encodeTestCodecValues(b, NewEncoder(&buf), v)
+	}
+}
+
+// TestEncoderAllocations verifies writing fixed width values and byte arrays
+// does not allocate for either a bytes.Buffer or another writer once the
+// encoder has been created.
+func TestEncoderAllocations(t *testing.T) {
+	var buf bytes.Buffer
+	v := []byte{1, 2, 3}
+	for _, e := range []*Encoder{NewEncoder(&buf), NewEncoder(io.Discard)} {
+		a := testing.AllocsPerRun(100, func() {
+			buf.Reset()
+			_ = e.WriteString("string")
+			_ = e.WriteByteArray(v)
+			_ = e.WriteByte(1)
+			_ = e.WriteUint16(1)
+			_ = e.WriteUint32(1)
+			_ = e.WriteUint64(1)
+			_ = e.WriteUvarint(1)
+			_ = e.WriteDate(DefaultEpoch().Base())
+		})
+		if a != 0 {
+			t.Fatalf("'%f' allocations", a)
+		}
+	}
+	a := testing.AllocsPerRun(100, func() {
+		buf.Reset()
+		_ = WriteUint32(&buf, 1)
+	})
+	if a != 0 {
+		t.Fatalf("'%f' allocations", a)
+	}
+}
@@ -0,0 +1,183 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"math"
+	"time"
+)
+
+// The base time of the default epoch.
+var defaultEpochBase = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Epoch is the base time from which dates are encoded as the number of days or
+// minutes that have elapsed. An Epoch can't be changed once created. The zero
+// value is the default epoch of 2020-01-01 UTC.
+type Epoch struct {
+	base time.Time
+}
+
+// NewEpoch returns an epoch with the base time provided converted to UTC.
+func NewEpoch(base time.Time) Epoch {
+	return Epoch{base: base.UTC()}
+}
+
+// DefaultEpoch returns the epoch of 2020-01-01 UTC used by the package level
+// functions and Encoders and Decoders that do not set Options.Epoch.
+func DefaultEpoch() Epoch {
+	return Epoch{base: defaultEpochBase}
+}
+
+// Base returns the base time of the epoch which is also the earliest date that
+// can be encoded.
+func (e Epoch) Base() time.Time {
+	if e.base.IsZero() {
+		return defaultEpochBase
+	}
+	return e.base
+}
+
+// MaxDate returns the latest date that can be encoded as days by WriteDate.
+func (e Epoch) MaxDate() time.Time {
+	return e.fromUnits(IoDateMaxDays, 24*time.Hour)
+}
+
+// MaxDateMinutes returns the latest date that can be encoded as minutes by
+// WriteDateToUInt32.
+func (e Epoch) MaxDateMinutes() time.Time {
+	return e.fromUnits(IoDateMaxMinutes, time.Minute)
+}
+
+// GetDateInDays returns the number of days that have elapsed since the epoch.
+// Returns ErrDateOutOfRange if the date is before the epoch or after MaxDate.
+func (e Epoch) GetDateInDays(t time.Time) (uint16, error) {
+	v, err := e.toUnits(t, 24*time.Hour, IoDateMaxDays)
+	return uint16(v), err
+}
+
+// GetDateFromDays returns the date from the days since the epoch provided.
+func (e Epoch) GetDateFromDays(d uint16) time.Time {
+	return e.fromUnits(uint64(d), 24*time.Hour)
+}
+
+// GetDateInMinutes returns the number of minutes that have elapsed since the
+// epoch. Returns ErrDateOutOfRange if the date is before the epoch or after
+// MaxDateMinutes.
+func (e Epoch) GetDateInMinutes(t time.Time) (uint32, error) {
+	v, err := e.toUnits(t, time.Minute, IoDateMaxMinutes)
+	return uint32(v), err
+}
+
+// GetDateFromMinutes returns the date from the minutes since the epoch
+// provided.
+func (e Epoch) GetDateFromMinutes(m uint32) time.Time {
+	return e.fromUnits(uint64(m), time.Minute)
+}
+
+// GetDateInSeconds returns the number of seconds that have elapsed since the
+// epoch. Returns ErrDateOutOfRange if the date is before the epoch or more
+// than IoDateMaxSeconds after it.
+func (e Epoch) GetDateInSeconds(t time.Time) (uint32, error) {
+	v, err := e.toUnits(t, time.Second, IoDateMaxSeconds)
+	return uint32(v), err
+}
+
+// GetDateFromSeconds returns the date from the seconds since the epoch
+// provided.
+func (e Epoch) GetDateFromSeconds(s uint32) time.Time {
+	return e.fromUnits(uint64(s), time.Second)
+}
+
+// GetDateInHours returns the number of hours that have elapsed since the
+// epoch. Returns ErrDateOutOfRange if the date is before the epoch or more than
+// IoDateMaxHours after it.
+func (e Epoch) GetDateInHours(t time.Time) (uint32, error) {
+	v, err := e.toUnits(t, time.Hour, IoDateMaxHours)
+	return uint32(v), err
+}
+
+// GetDateInHours16 returns the number of hours that have elapsed since the
+// epoch. Returns ErrDateOutOfRange if the date is before the epoch or more than
+// IoDateMaxHours16 after it.
+func (e Epoch) GetDateInHours16(t time.Time) (uint16, error) {
+	v, err := e.toUnits(t, time.Hour, IoDateMaxHours16)
+	return uint16(v), err
+}
+
+// GetDateFromHours returns the date from the hours since the epoch provided.
+func (e Epoch) GetDateFromHours(h uint32) time.Time {
+	return e.fromUnits(uint64(h), time.Hour)
+}
+
+// GetDateInMilliseconds returns the number of milliseconds that have elapsed
+// since the epoch. Returns ErrDateOutOfRange if the date is before the epoch
+// or more than IoDateMaxMilliseconds after it.
+func (e Epoch) GetDateInMilliseconds(t time.Time) (uint64, error) {
+	return e.toUnits(t, time.Millisecond, IoDateMaxMilliseconds)
+}
+
+// GetDateFromMilliseconds returns the date from the milliseconds since the
+// epoch provided.
+func (e Epoch) GetDateFromMilliseconds(m uint64) time.Time {
+	return e.fromUnits(m, time.Millisecond)
+}
+
+// toUnits returns the number of whole units of duration u that have elapsed
+// between the epoch and the date t. Returns ErrDateOutOfRange if t is before
+// the epoch or more than m units after it. Uses the Unix seconds so that dates
+// far from the epoch do not overflow time.Duration, and o records overflow of
+// units smaller than a second.
+func (e Epoch) toUnits(t time.Time, u time.Duration, m uint64) (uint64, error) {
+	b := e.Base()
+	s := t.Unix() - b.Unix()
+	n := int64(t.Nanosecond() - b.Nanosecond())
+	if n < 0 {
+		s--
+		n += int64(time.Second)
+	}
+	var v int64
+	o := false
+	if u >= time.Second {
+		v = s / int64(u/time.Second)
+		if s < 0 && s%int64(u/time.Second) != 0 {
+			v--
+		}
+	} else {
+		p := int64(time.Second / u)
+		q := int64(math.MaxInt64) / p
+		if s > q || (s == q && n/int64(u) > math.MaxInt64%p) {
+			o = true
+		} else {
+			v = s*p + n/int64(u)
+		}
+	}
+	if o || v < 0 || uint64(v) > m {
+		return 0, &ErrDateOutOfRange{
+			Date: t,
+			Min:  b,
+			Max:  e.fromUnits(m, u)}
+	}
+	return uint64(v), nil
+}
+
+// fromUnits returns the date that is n units of duration u after the epoch.
+// Whole days are added first so that large values of n do not overflow
+// time.Duration.
+func (e Epoch) fromUnits(n uint64, u time.Duration) time.Time {
+	d := uint64(24 * time.Hour / u)
+	return e.Base().AddDate(0, 0, int(n/d)).Add(time.Duration(n%d) * u)
+}
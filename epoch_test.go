@@ -0,0 +1,146 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// TestEpoch verifies dates are encoded relative to the epoch in the options
+// and that the zero epoch is the default epoch.
+func TestEpoch(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		if !(Epoch{}).Base().Equal(DefaultEpoch().Base()) {
+			t.Fatal("zero epoch is not the default epoch")
+		}
+		if DefaultEpoch().Base().Year() != 2020 {
+			t.Fatal("default epoch is not 2020")
+		}
+	})
+	t.Run("custom", func(t *testing.T) {
+		e := NewEpoch(time.Date(2010, time.January, 1, 0, 0, 0, 0, time.UTC))
+		d := time.Date(2015, time.June, 1, 0, 0, 0, 0, time.UTC)
+		if err := WriteDate(&bytes.Buffer{}, d); err == nil {
+			t.Fatal("expected date before default epoch to fail")
+		}
+		var b bytes.Buffer
+		o := Options{Epoch: e}
+		if err := NewEncoderWithOptions(&b, o).WriteDate(d); err != nil {
+			t.Fatal(err)
+		}
+		days, err := e.GetDateInDays(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !e.GetDateFromDays(days).Equal(d) {
+			t.Fatal("days did not round trip")
+		}
+		r, err := NewDecoderWithOptions(&b, o).ReadDate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r.Equal(d) {
+			t.Fatalf("'%s' != '%s'", r, d)
+		}
+	})
+	t.Run("utc", func(t *testing.T) {
+		z := time.FixedZone("", 3600)
+		e := NewEpoch(time.Date(2010, time.January, 1, 1, 0, 0, 0, z))
+		if e.Base().Location() != time.UTC {
+			t.Fatal("epoch not in UTC")
+		}
+		if e.Base().Hour() != 0 {
+			t.Fatal("epoch instant changed")
+		}
+	})
+	t.Run("io date base", func(t *testing.T) {
+		o := IoDateBase
+		defer func() { IoDateBase = o }()
+		IoDateBase = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		if GetDateInMinutes(DefaultEpoch().Base().Add(time.Hour)) != 60 {
+			t.Fatal("changing IoDateBase affected encoding")
+		}
+	})
+	t.Run("io date min", func(t *testing.T) {
+		o := IoDateMin
+		defer func() { IoDateMin = o }()
+		IoDateMin = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+		if GetDateInHours(DefaultEpoch().Base().Add(-time.Hour)) != 0 {
+			t.Fatal("changing IoDateMin affected clamping")
+		}
+	})
+}
+
+// TestEpochResolutions verifies the seconds, hours and milliseconds encodings
+// round trip, truncate to the resolution, and reject dates out of range.
+func TestEpochResolutions(t *testing.T) {
+	d := time.Date(2022, time.March, 4, 5, 6, 7, 891234567, time.UTC)
+	for _, r := range []struct {
+		name  string
+		write func(*bytes.Buffer, time.Time) error
+		read  func(*bytes.Buffer) (time.Time, error)
+		unit  time.Duration
+		size  int
+		max   time.Time
+	}{
+		{"seconds", WriteDateSeconds, ReadDateSeconds, time.Second, 4,
+			GetDateFromSeconds(IoDateMaxSeconds)},
+		{"hours", WriteDateHours, ReadDateHours, time.Hour, 4,
+			GetDateFromHours(IoDateMaxHours)},
+		{"hours16", WriteDateHours16, ReadDateHours16, time.Hour, 2,
+			GetDateFromHours(IoDateMaxHours16)},
+		{"milliseconds", WriteDateMilliseconds, ReadDateMilliseconds,
+			time.Millisecond, 8,
+			GetDateFromMilliseconds(IoDateMaxMilliseconds)},
+	} {
+		t.Run(r.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := r.write(&b, d); err != nil {
+				t.Fatal(err)
+			}
+			if b.Len() != r.size {
+				t.Fatalf("wrote '%d' bytes", b.Len())
+			}
+			v, err := r.read(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !v.Equal(d.Truncate(r.unit)) {
+				t.Fatalf("'%s' != '%s'", v, d.Truncate(r.unit))
+			}
+			testDateRange(t, r.write, r.read, r.max, r.unit)
+		})
+	}
+	t.Run("conversions", func(t *testing.T) {
+		if !GetDateFromSeconds(GetDateInSeconds(d)).Equal(
+			d.Truncate(time.Second)) {
+			t.Fatal("seconds")
+		}
+		if !GetDateFromHours(GetDateInHours(d)).Equal(d.Truncate(time.Hour)) {
+			t.Fatal("hours")
+		}
+		if !GetDateFromMilliseconds(GetDateInMilliseconds(d)).Equal(
+			d.Truncate(time.Millisecond)) {
+			t.Fatal("milliseconds")
+		}
+		if GetDateInHours(DefaultEpoch().Base().Add(-time.Hour)) != 0 {
+			t.Fatal("expected zero before epoch")
+		}
+	})
+}
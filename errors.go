@@ -0,0 +1,163 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrTooLong is returned when a value can't be written because its length or
+// number of elements exceeds either the maximum the format can represent or a
+// limit set in the Options.
+type ErrTooLong struct {
+	Field  string // the type of value being written
+	Length int64  // the length or count of the value
+	Limit  int64  // the maximum permitted
+}
+
+func (e *ErrTooLong) Error() string {
+	return fmt.Sprintf(
+		"%s length '%d' exceeds limit '%d'",
+		e.Field,
+		e.Length,
+		e.Limit)
+}
+
+// ErrTruncated is returned when the data ends before a value has been fully
+// read. For example if the length of a byte array exceeds the bytes remaining
+// or a string is missing its null terminator. Wraps io.ErrUnexpectedEOF.
+type ErrTruncated struct {
+	Field     string // the type of value being read
+	Expected  int64  // the number of bytes required
+	Available int64  // the number of bytes that were available
+}
+
+func (e *ErrTruncated) Error() string {
+	return fmt.Sprintf(
+		"%s truncated, expected '%d' bytes but '%d' available",
+		e.Field,
+		e.Expected,
+		e.Available)
+}
+
+func (e *ErrTruncated) Unwrap() error {
+	return io.ErrUnexpectedEOF
+}
+
+// ErrLimitExceeded is returned by a Decoder when the data would exceed one of
+// the Limits set in the Options, and by DecodeFromURL and DecodeFromCookie
+// when the string is longer than IoMaxURLLength or IoMaxCookieLength.
+type ErrLimitExceeded struct {
+	Limit string // the name of the field in Limits or the constant
+	Value int64  // the value that exceeded the limit
+	Max   int64  // the maximum permitted by the limit
+}
+
+func (e *ErrLimitExceeded) Error() string {
+	return fmt.Sprintf(
+		"%s of '%d' exceeded with '%d'",
+		e.Limit,
+		e.Max,
+		e.Value)
+}
+
+// ErrDateOutOfRange is returned when a date is outside the range that can be
+// represented by the encoding used.
+type ErrDateOutOfRange struct {
+	Date time.Time // the date that could not be written
+	Min  time.Time // the earliest date that can be written
+	Max  time.Time // the latest date that can be written
+}
+
+func (e *ErrDateOutOfRange) Error() string {
+	return fmt.Sprintf(
+		"date '%s' outside range '%s' to '%s'",
+		e.Date.Format(time.RFC3339),
+		e.Min.Format(time.RFC3339),
+		e.Max.Format(time.RFC3339))
+}
+
+// ErrInvalidMagic is returned by ReadHeader when the first byte is not
+// IoHeaderMagic. Usually because the data was not written with WriteHeader.
+type ErrInvalidMagic struct {
+	Magic byte // the byte found in place of IoHeaderMagic
+}
+
+func (e *ErrInvalidMagic) Error() string {
+	return fmt.Sprintf(
+		"magic byte '0x%02x' not '0x%02x'",
+		e.Magic,
+		IoHeaderMagic)
+}
+
+// ErrUnsupportedVersion is returned when there is no decoder registered for
+// the format version in a header.
+type ErrUnsupportedVersion struct {
+	Version byte // the version in the header
+}
+
+func (e *ErrUnsupportedVersion) Error() string {
+	return fmt.Sprintf("format version '%d' not supported", e.Version)
+}
+
+// ErrChecksum is returned by ReadFrame when the checksum of the payload does
+// not match the checksum that was written with it. Indicates the data has been
+// corrupted.
+type ErrChecksum struct {
+	Expected uint32 // the checksum read from the frame
+	Actual   uint32 // the checksum of the payload read
+}
+
+func (e *ErrChecksum) Error() string {
+	return fmt.Sprintf(
+		"checksum '0x%08x' does not match payload checksum '0x%08x'",
+		e.Expected,
+		e.Actual)
+}
+
+// ErrEmbeddedNull is returned when a null terminated string contains a zero
+// byte. The string would be truncated when read. Use WriteStringL for strings
+// that can contain zero bytes.
+type ErrEmbeddedNull struct {
+	Index int // the position of the first zero byte in the string
+}
+
+func (e *ErrEmbeddedNull) Error() string {
+	return fmt.Sprintf("string contains zero byte at index '%d'", e.Index)
+}
+
+// ErrInvalidUTF8 is returned when the ValidateUTF8 option is set and a string
+// is not valid UTF-8, and by ToJSON for any string that is not valid UTF-8.
+type ErrInvalidUTF8 struct {
+	Index int // the position of the first invalid byte in the string
+}
+
+func (e *ErrInvalidUTF8) Error() string {
+	return fmt.Sprintf("string invalid UTF-8 at index '%d'", e.Index)
+}
+
+// ErrNotCanonical is returned by a Decoder with the Canonical option set when
+// the data is not in the canonical form.
+type ErrNotCanonical struct {
+	Reason string // why the data is not canonical
+}
+
+func (e *ErrNotCanonical) Error() string {
+	return fmt.Sprintf("not canonical: %s", e.Reason)
+}
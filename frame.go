@@ -0,0 +1,84 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"hash/crc32"
+)
+
+// The CRC32C (Castagnoli) table used to checksum frames.
+var frameTable = crc32.MakeTable(crc32.Castagnoli)
+
+// WriteFrame writes the length of the payload, the payload and then the
+// CRC32C checksum of the payload as an unsigned 32 bit integer. Used to detect
+// corruption of stored or transmitted records.
+func (e *Encoder) WriteFrame(p []byte) error {
+	err := e.writeLength("frame", len(p), 0)
+	if err != nil {
+		return err
+	}
+	err = e.WriteByteArrayNoLength(p)
+	if err != nil {
+		return err
+	}
+	return e.WriteUint32(crc32.Checksum(p, frameTable))
+}
+
+// WriteFramed writes the values written by f to a new Encoder with the same
+// options as a frame.
+func (e *Encoder) WriteFramed(f func(e *Encoder) error) error {
+	b := GetBuffer()
+	defer PutBuffer(b)
+	err := f(NewEncoderWithOptions(b, e.o))
+	if err != nil {
+		return err
+	}
+	return e.WriteFrame(b.Bytes())
+}
+
+// ReadFrame reads a frame written by WriteFrame and returns the payload.
+// Returns ErrChecksum if the payload does not match the checksum so that
+// corrupt data is detected before any fields are parsed.
+func (d *Decoder) ReadFrame() ([]byte, error) {
+	l, err := d.readLength("MaxBytes", d.o.Limits.MaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	p, err := d.read("frame", l)
+	if err != nil {
+		return nil, err
+	}
+	c, err := d.ReadUint32()
+	if err != nil {
+		return nil, err
+	}
+	if a := crc32.Checksum(p, frameTable); a != c {
+		return nil, &ErrChecksum{Expected: c, Actual: a}
+	}
+	return p, nil
+}
+
+// ReadFramed reads a frame and returns a new Decoder with the same options
+// to read the values from the verified payload.
+func (d *Decoder) ReadFramed() (*Decoder, error) {
+	p, err := d.ReadFrame()
+	if err != nil {
+		return nil, err
+	}
+	return NewDecoderWithOptions(bytes.NewBuffer(p), d.o), nil
+}
@@ -0,0 +1,90 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestFrame verifies payloads round trip and corruption of any byte of the
+// payload or checksum is detected.
+func TestFrame(t *testing.T) {
+	p := []byte("payload")
+	var b bytes.Buffer
+	if err := WriteFrame(&b, p); err != nil {
+		t.Fatal(err)
+	}
+	f := append([]byte(nil), b.Bytes()...)
+	r, err := ReadFrame(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r, p) {
+		t.Fatalf("payload '%v' != '%v'", r, p)
+	}
+	for i := 4; i < len(f); i++ {
+		c := append([]byte(nil), f...)
+		c[i] ^= 0x01
+		_, err := ReadFrame(bytes.NewBuffer(c))
+		var e *ErrChecksum
+		if !errors.As(err, &e) {
+			t.Fatalf("byte '%d' expected ErrChecksum but got '%v'", i, err)
+		}
+	}
+	t.Run("truncated", func(t *testing.T) {
+		_, err := ReadFrame(bytes.NewBuffer(f[:len(f)-1]))
+		var e *ErrTruncated
+		if !errors.As(err, &e) {
+			t.Fatalf("expected ErrTruncated but got '%v'", err)
+		}
+	})
+}
+
+// TestFramed verifies values written to a frame are read with the same
+// options and that corrupt frames fail before any fields are read.
+func TestFramed(t *testing.T) {
+	o := Options{Varint: true}
+	var b bytes.Buffer
+	err := NewEncoderWithOptions(&b, o).WriteFramed(func(e *Encoder) error {
+		if err := e.WriteString("name"); err != nil {
+			return err
+		}
+		return e.WriteUint16(7)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := append([]byte(nil), b.Bytes()...)
+	d, err := NewDecoderWithOptions(&b, o).ReadFramed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err := d.ReadString(); err != nil || s != "name" {
+		t.Fatalf("string '%s' incorrect: %v", s, err)
+	}
+	if i, err := d.ReadUint16(); err != nil || i != 7 {
+		t.Fatalf("uint16 '%d' incorrect: %v", i, err)
+	}
+	f[2] ^= 0xff
+	_, err = NewDecoderWithOptions(bytes.NewBuffer(f), o).ReadFramed()
+	var e *ErrChecksum
+	if !errors.As(err, &e) {
+		t.Fatalf("expected ErrChecksum but got '%v'", err)
+	}
+}
@@ -0,0 +1,113 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Header precedes a payload to record the layout version it was written with
+// so that older payloads remain readable after the layout changes.
+type Header struct {
+	Version byte // the version of the layout of the payload
+	Flags   byte // bit flags defined by the payload
+}
+
+// Has returns true if all the bits of flag are set in the header's flags.
+func (h Header) Has(flag byte) bool {
+	return h.Flags&flag == flag
+}
+
+// WriteHeader writes IoHeaderMagic followed by the version and flags.
+func (e *Encoder) WriteHeader(h Header) error {
+	return e.write([]byte{IoHeaderMagic, h.Version, h.Flags})
+}
+
+// ReadHeader reads a header written by WriteHeader. Returns ErrInvalidMagic if
+// the first byte is not IoHeaderMagic.
+func (d *Decoder) ReadHeader() (Header, error) {
+	b, err := d.read("header", IoHeaderLength)
+	if err != nil {
+		return Header{}, err
+	}
+	if b[0] != IoHeaderMagic {
+		return Header{}, &ErrInvalidMagic{Magic: b[0]}
+	}
+	return Header{Version: b[1], Flags: b[2]}, nil
+}
+
+// VersionDecoder reads a payload with the layout version of the header.
+type VersionDecoder[T any] func(d *Decoder, h Header) (T, error)
+
+// Registry contains the decoders for each layout version of a payload. Safe
+// for concurrent use.
+type Registry[T any] struct {
+	mutex    sync.RWMutex
+	decoders map[byte]VersionDecoder[T]
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry[T any]() *Registry[T] {
+	return &Registry[T]{decoders: make(map[byte]VersionDecoder[T])}
+}
+
+// Register adds the decoder for the version. Returns an error if a decoder is
+// already registered for the version.
+func (r *Registry[T]) Register(version byte, f VersionDecoder[T]) error {
+	if f == nil {
+		return fmt.Errorf("decoder for version '%d' is nil", version)
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.decoders[version]; ok {
+		return fmt.Errorf("decoder for version '%d' already registered", version)
+	}
+	r.decoders[version] = f
+	return nil
+}
+
+// Versions returns the registered versions in ascending order.
+func (r *Registry[T]) Versions() []byte {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	v := make([]byte, 0, len(r.decoders))
+	for k := range r.decoders {
+		v = append(v, k)
+	}
+	sort.Slice(v, func(i, j int) bool { return v[i] < v[j] })
+	return v
+}
+
+// Decode reads the header and then the payload with the decoder registered for
+// the header's version. Returns ErrUnsupportedVersion if there is no decoder
+// for the version.
+func (r *Registry[T]) Decode(d *Decoder) (T, error) {
+	var v T
+	h, err := d.ReadHeader()
+	if err != nil {
+		return v, err
+	}
+	r.mutex.RLock()
+	f, ok := r.decoders[h.Version]
+	r.mutex.RUnlock()
+	if !ok {
+		return v, &ErrUnsupportedVersion{Version: h.Version}
+	}
+	return f(d, h)
+}
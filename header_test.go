@@ -0,0 +1,161 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestHeader verifies the header round trips and invalid headers fail.
+func TestHeader(t *testing.T) {
+	var b bytes.Buffer
+	h := Header{Version: 2, Flags: 0x05}
+	if err := WriteHeader(&b, h); err != nil {
+		t.Fatal(err)
+	}
+	if b.Len() != IoHeaderLength {
+		t.Fatalf("header length '%d' not '%d'", b.Len(), IoHeaderLength)
+	}
+	r, err := ReadHeader(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r != h {
+		t.Fatalf("header '%v' != '%v'", r, h)
+	}
+	if !r.Has(0x04) || !r.Has(0x05) || r.Has(0x02) {
+		t.Fatal("flags incorrect")
+	}
+	t.Run("invalid magic", func(t *testing.T) {
+		_, err := ReadHeader(bytes.NewBuffer([]byte{0, 1, 0}))
+		var e *ErrInvalidMagic
+		if !errors.As(err, &e) || e.Magic != 0 {
+			t.Fatalf("expected ErrInvalidMagic but got '%v'", err)
+		}
+	})
+	t.Run("truncated", func(t *testing.T) {
+		_, err := ReadHeader(bytes.NewBuffer([]byte{IoHeaderMagic, 1}))
+		var e *ErrTruncated
+		if !errors.As(err, &e) {
+			t.Fatalf("expected ErrTruncated but got '%v'", err)
+		}
+	})
+}
+
+// testPayload is the current layout of a payload where version 1 only
+// contained the name.
+type testPayload struct {
+	name  string
+	count uint16
+}
+
+func newTestRegistry(t *testing.T) *Registry[testPayload] {
+	r := NewRegistry[testPayload]()
+	err := r.Register(1, func(d *Decoder, h Header) (testPayload, error) {
+		n, err := d.ReadString()
+		return testPayload{name: n}, err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = r.Register(2, func(d *Decoder, h Header) (testPayload, error) {
+		var p testPayload
+		var err error
+		if p.name, err = d.ReadString(); err != nil {
+			return p, err
+		}
+		p.count, err = d.ReadUint16()
+		return p, err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// TestRegistry verifies payloads of each version are read with the decoder
+// registered for the version.
+func TestRegistry(t *testing.T) {
+	r := newTestRegistry(t)
+	if v := r.Versions(); !bytes.Equal(v, []byte{1, 2}) {
+		t.Fatalf("versions '%v' incorrect", v)
+	}
+
+	// Version 1 payload.
+	var b bytes.Buffer
+	e := NewEncoder(&b)
+	if err := e.WriteHeader(Header{Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteString("old"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Version 2 payload.
+	if err := e.WriteHeader(Header{Version: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteString("new"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteUint16(3); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDecoder(&b)
+	for _, w := range []testPayload{{"old", 0}, {"new", 3}} {
+		p, err := r.Decode(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p != w {
+			t.Fatalf("payload '%v' != '%v'", p, w)
+		}
+	}
+}
+
+// TestRegistryErrors verifies unsupported versions and invalid registrations
+// result in errors.
+func TestRegistryErrors(t *testing.T) {
+	r := newTestRegistry(t)
+	t.Run("unsupported version", func(t *testing.T) {
+		var b bytes.Buffer
+		if err := WriteHeader(&b, Header{Version: 3}); err != nil {
+			t.Fatal(err)
+		}
+		_, err := r.Decode(NewDecoder(&b))
+		var e *ErrUnsupportedVersion
+		if !errors.As(err, &e) || e.Version != 3 {
+			t.Fatalf("expected ErrUnsupportedVersion but got '%v'", err)
+		}
+	})
+	t.Run("duplicate", func(t *testing.T) {
+		err := r.Register(1, func(d *Decoder, h Header) (testPayload, error) {
+			return testPayload{}, nil
+		})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("nil", func(t *testing.T) {
+		if err := r.Register(4, nil); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
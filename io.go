@@ -19,326 +19,475 @@ package common
 import (
 	"bytes"
 	"encoding"
-	"encoding/binary"
-	"fmt"
-	"math"
 	"time"
 )
 
-// WriteStrings one dimensional array of strings.
+// WriteStrings one dimensional array of strings. Returns ErrTooLong if there
+// are more strings than can be counted in an unsigned 16 bit integer.
 func WriteStrings(b *bytes.Buffer, v []string) error {
-	err := WriteUint16(b, uint16(len(v)))
-	if err != nil {
-		return err
-	}
-	for _, i := range v {
-		err = WriteString(b, i)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return NewEncoder(b).WriteStrings(v)
 }
 
 // ReadStrings into one dimensional array of strings.
 func ReadStrings(b *bytes.Buffer) ([]string, error) {
-	c, err := ReadUint16(b)
-	if err != nil {
-		return nil, err
-	}
-	v := make([]string, c, c)
-	for i := uint16(0); i < c; i++ {
-		v[i], err = ReadString(b)
-		if err != nil {
-			return nil, err
-		}
-	}
-	return v, nil
+	return NewDecoder(b).ReadStrings()
 }
 
-// WriteByteArrayArray two dimensional array of bytes.
+// WriteByteArrayArray two dimensional array of bytes. Returns ErrTooLong if
+// there are more arrays than can be counted in an unsigned 16 bit integer.
 func WriteByteArrayArray(b *bytes.Buffer, v [][]byte) error {
-	err := WriteUint16(b, uint16(len(v)))
-	if err != nil {
-		return err
-	}
-	for _, i := range v {
-		err = WriteByteArray(b, i)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return NewEncoder(b).WriteByteArrayArray(v)
 }
 
-// ReadByteArrayArray from two dimensional array of bytes.
+// ReadByteArrayArray from two dimensional array of bytes. The arrays reference
+// the buffer's storage in the same way as ReadByteArray.
 func ReadByteArrayArray(b *bytes.Buffer) ([][]byte, error) {
-	c, err := ReadUint16(b)
-	if err != nil {
-		return nil, err
-	}
-	v := make([][]byte, c, c)
-	for i := uint16(0); i < c; i++ {
-		v[i], err = ReadByteArray(b)
-		if err != nil {
-			return nil, err
-		}
-	}
-	return v, nil
+	return NewDecoder(b).ReadByteArrayArray()
+}
+
+// WriteStringMap writes the number of entries as an unsigned 16 bit integer
+// followed by each key and value as null terminated strings in ascending key
+// order.
+func WriteStringMap(b *bytes.Buffer, m map[string]string) error {
+	return NewEncoder(b).WriteStringMap(m)
+}
+
+// ReadStringMap reads a map written by WriteStringMap.
+func ReadStringMap(b *bytes.Buffer) (map[string]string, error) {
+	return NewDecoder(b).ReadStringMap()
+}
+
+// WriteUint32s writes the number of integers as an unsigned 16 bit integer
+// followed by each integer.
+func WriteUint32s(b *bytes.Buffer, v []uint32) error {
+	return NewEncoder(b).WriteUint32s(v)
+}
+
+// ReadUint32s reads integers written by WriteUint32s.
+func ReadUint32s(b *bytes.Buffer) ([]uint32, error) {
+	return NewDecoder(b).ReadUint32s()
+}
+
+// WriteCount writes the number of elements in an array as an unsigned 16 bit
+// integer in the same way as WriteStrings and WriteByteArrayArray. Used before
+// writing the elements of arrays of other types. Returns ErrTooLong if the
+// count can't be represented.
+func WriteCount(b *bytes.Buffer, c int) error {
+	return NewEncoder(b).WriteCount(c)
+}
+
+// ReadCount reads the number of elements in an array written by WriteCount.
+func ReadCount(b *bytes.Buffer) (int, error) {
+	return NewDecoder(b).ReadCount()
 }
 
 // WriteFloat32 to the buffer.
 func WriteFloat32(b *bytes.Buffer, f float32) error {
-	return WriteUint32(b, math.Float32bits(f))
+	return NewEncoder(b).WriteFloat32(f)
 }
 
 // ReadFloat32 from the buffer.
 func ReadFloat32(b *bytes.Buffer) (float32, error) {
-	f, err := ReadUint32(b)
-	if err != nil {
-		return 0, err
-	}
-	return math.Float32frombits(f), nil
+	return NewDecoder(b).ReadFloat32()
+}
+
+// WriteFloat64 to the buffer.
+func WriteFloat64(b *bytes.Buffer, f float64) error {
+	return NewEncoder(b).WriteFloat64(f)
+}
+
+// ReadFloat64 from the buffer.
+func ReadFloat64(b *bytes.Buffer) (float64, error) {
+	return NewDecoder(b).ReadFloat64()
 }
 
-// ReadTime from the next binary object.
+// ReadTime from the next binary object. Returns an error if the object is not
+// a valid encoding of a time.
 func ReadTime(b *bytes.Buffer) (time.Time, error) {
-	var t time.Time
-	d, err := ReadByteArray(b)
-	if err == nil {
-		t.GobDecode(d)
-	}
-	return t, err
+	return NewDecoder(b).ReadTime()
 }
 
 // WriteTime as a binary object.
 func WriteTime(b *bytes.Buffer, t time.Time) error {
-	d, err := t.GobEncode()
-	if err != nil {
-		return err
-	}
-	return WriteByteArray(b, d)
+	return NewEncoder(b).WriteTime(t)
+}
+
+// ReadTimeCompact reads a time written by WriteTimeCompact.
+func ReadTimeCompact(b *bytes.Buffer) (time.Time, error) {
+	return NewDecoder(b).ReadTimeCompact()
+}
+
+// WriteTimeCompact writes the time as a fixed 14 bytes comprising the Unix
+// seconds, nanoseconds and zone offset in minutes. Uses less space than
+// WriteTime but does not preserve the name of the location.
+func WriteTimeCompact(b *bytes.Buffer, t time.Time) error {
+	return NewEncoder(b).WriteTimeCompact(t)
 }
 
 // ReadDate reads the date from the unsigned 16 bit integer and then determines
-// the date by adding this to the IoDateBase epoch.
+// the date by adding this to the default epoch.
 func ReadDate(b *bytes.Buffer) (time.Time, error) {
-	d, err := ReadUint16(b)
-	if err != nil {
-		return time.Time{}, err
-	}
-	return IoDateBase.Add(time.Duration(d) * time.Hour * 24), nil
+	return NewDecoder(b).ReadDate()
 }
 
-// WriteDate writes the date as the number of hours since the IoDateBase epoch.
-// Uses an unsigned 16 bit integer.
+// WriteDate writes the date as the number of days since the default epoch.
+// Uses an unsigned 16 bit integer. Returns ErrDateOutOfRange if the date is
+// before DefaultEpoch().Base() or after DefaultEpoch().MaxDate().
 func WriteDate(b *bytes.Buffer, t time.Time) error {
-	return WriteUint16(b, uint16(t.Sub(IoDateBase).Hours()/24))
+	return NewEncoder(b).WriteDate(t)
 }
 
 // ReadMarshaller reads the content into the unmarshaler instance.
 func ReadMarshaller(b *bytes.Buffer, m encoding.BinaryUnmarshaler) error {
-	v, err := ReadByteArray(b)
-	if err != nil {
-		return err
-	}
-	return m.UnmarshalBinary(v)
+	return NewDecoder(b).ReadMarshaller(m)
 }
 
 // WriteMarshaller writes the result of marshal binary call to the buffer.
 func WriteMarshaller(b *bytes.Buffer, m encoding.BinaryMarshaler) error {
-	v, err := m.MarshalBinary()
-	if err != nil {
-		return err
-	}
-	return WriteByteArray(b, v)
+	return NewEncoder(b).WriteMarshaller(m)
 }
 
 // ReadString reads a null (zero) terminated string from the byte buffer.
+// Returns io.EOF if the buffer is empty and ErrTruncated if the terminator is
+// missing.
 func ReadString(b *bytes.Buffer) (string, error) {
-	s, err := b.ReadBytes(0)
-	if err == nil {
-		return string(s[0 : len(s)-1]), err
-	}
-	return "", err
+	return NewDecoder(b).ReadString()
 }
 
 // WriteString writes a null (zero) terminated string to the byte buffer.
+// Returns ErrEmbeddedNull if the string contains a zero byte. Use WriteStringL
+// for strings that can contain zero bytes.
 func WriteString(b *bytes.Buffer, s string) error {
-	l, err := b.WriteString(s)
-	if err == nil {
+	return NewEncoder(b).WriteString(s)
+}
 
-		// Validate the number of bytes written matches the number of bytes in
-		// the string.
-		if l != len(s) {
-			return fmt.Errorf(
-				"mismatched lengths '%d' and '%d'",
-				l,
-				len(s))
-		}
-
-		// Write the null terminator.
-		b.WriteByte(0)
-	}
-	return err
+// ReadStringL reads a string written by WriteStringL. Returns ErrTruncated if
+// the buffer contains fewer bytes than the length.
+func ReadStringL(b *bytes.Buffer) (string, error) {
+	return NewDecoder(b).ReadStringL()
+}
+
+// WriteStringL writes the length of the string as an unsigned 32 bit integer
+// followed by the bytes of the string. The string can contain zero bytes.
+func WriteStringL(b *bytes.Buffer, s string) error {
+	return NewEncoder(b).WriteStringL(s)
 }
 
 // ReadByteArray reads the first 4 bytes as an unsigned 32 bit integer to
 // determine the length of the byte array contained in the following bytes.
+// Returns ErrTruncated if the buffer contains fewer bytes than the length.
+// The slice returned references the buffer's storage and is only valid until
+// the buffer is next modified. Use ReadByteArrayCopy to retain the slice.
 func ReadByteArray(b *bytes.Buffer) ([]byte, error) {
-	l, err := ReadUint32(b)
-	if err != nil {
-		return nil, err
-	}
-	return b.Next(int(l)), err
+	return NewDecoder(b).ReadByteArray()
+}
+
+// ReadByteArrayCopy is the same as ReadByteArray but returns a copy of the
+// bytes that is safe to retain after the buffer is reused.
+func ReadByteArrayCopy(b *bytes.Buffer) ([]byte, error) {
+	return NewDecoder(b).ReadByteArrayCopy()
 }
 
-// ReadByteArray writes the length of the byte array as an unsigned 32 bit
+// WriteByteArray writes the length of the byte array as an unsigned 32 bit
 // integer followed by the bytes.
 func WriteByteArray(b *bytes.Buffer, v []byte) error {
-	err := WriteUint32(b, uint32(len(v)))
-	if err != nil {
-		return err
-	}
-	return WriteByteArrayNoLength(b, v)
+	return NewEncoder(b).WriteByteArray(v)
 }
 
-// ReadByteArrayNoLength reads the number of bytes specified into a new byte
-// array.
+// ReadByteArrayNoLength reads the number of bytes specified. Returns
+// ErrTruncated if the buffer contains fewer bytes. The slice returned
+// references the buffer's storage in the same way as ReadByteArray.
 func ReadByteArrayNoLength(b *bytes.Buffer, l int) ([]byte, error) {
-	v := b.Next(l)
-	if len(v) != l {
-		return nil, fmt.Errorf("read '%d' bytes but expected '%d'", len(v), l)
-	}
-	return v, nil
+	return NewDecoder(b).ReadByteArrayNoLength(l)
+}
+
+// ReadByteArrayNoLengthCopy is the same as ReadByteArrayNoLength but returns a
+// copy of the bytes that is safe to retain after the buffer is reused.
+func ReadByteArrayNoLengthCopy(b *bytes.Buffer, l int) ([]byte, error) {
+	return NewDecoder(b).ReadByteArrayNoLengthCopy(l)
 }
 
 // WriteByteArrayNoLength writes the byte array to the buffer without recording
 // the length. Used with fixed length data.
 func WriteByteArrayNoLength(b *bytes.Buffer, v []byte) error {
-	l, err := b.Write(v)
-	if err == nil {
-		if l != len(v) {
-			return fmt.Errorf(
-				"mismatched lengths '%d' and '%d'",
-				l,
-				len(v))
-		}
-	}
-	return err
+	return NewEncoder(b).WriteByteArrayNoLength(v)
 }
 
 // GetDateInMinutes returns the number of minutes that have elapsed since the
-// IoDateBase epoch.
+// default epoch. Dates before DefaultEpoch().Base() return 0 and dates after
+// DefaultEpoch().MaxDateMinutes() return IoDateMaxMinutes. Use
+// Epoch.GetDateInMinutes to have an error returned for dates outside this
+// range.
 func GetDateInMinutes(t time.Time) uint32 {
-	return uint32(t.Sub(IoDateBase).Minutes())
+	v, err := DefaultEpoch().GetDateInMinutes(t)
+	return uint32(clampUnits(t, uint64(v), err, IoDateMaxMinutes))
 }
 
 // GetTimeFromMinutes returns the date time from the minutes provided.
 func GetDateFromMinutes(t uint32) time.Time {
-	return IoDateBase.Add(time.Minute * time.Duration(t))
+	return DefaultEpoch().GetDateFromMinutes(t)
+}
+
+// GetDateInSeconds returns the number of seconds that have elapsed since the
+// default epoch. Dates outside the range that can be stored are clamped to 0
+// or IoDateMaxSeconds. Use Epoch.GetDateInSeconds to have an error returned.
+func GetDateInSeconds(t time.Time) uint32 {
+	v, err := DefaultEpoch().GetDateInSeconds(t)
+	return uint32(clampUnits(t, uint64(v), err, IoDateMaxSeconds))
+}
+
+// GetDateFromSeconds returns the date time from the seconds provided.
+func GetDateFromSeconds(s uint32) time.Time {
+	return DefaultEpoch().GetDateFromSeconds(s)
+}
+
+// GetDateInHours returns the number of hours that have elapsed since the
+// default epoch. Dates outside the range that can be stored are clamped to 0
+// or IoDateMaxHours. Use Epoch.GetDateInHours to have an error returned.
+func GetDateInHours(t time.Time) uint32 {
+	v, err := DefaultEpoch().GetDateInHours(t)
+	return uint32(clampUnits(t, uint64(v), err, IoDateMaxHours))
+}
+
+// GetDateFromHours returns the date time from the hours provided.
+func GetDateFromHours(h uint32) time.Time {
+	return DefaultEpoch().GetDateFromHours(h)
+}
+
+// GetDateInMilliseconds returns the number of milliseconds that have elapsed
+// since the default epoch. Dates outside the range that can be stored are
+// clamped to 0 or IoDateMaxMilliseconds. Use Epoch.GetDateInMilliseconds to
+// have an error returned.
+func GetDateInMilliseconds(t time.Time) uint64 {
+	v, err := DefaultEpoch().GetDateInMilliseconds(t)
+	return clampUnits(t, v, err, IoDateMaxMilliseconds)
+}
+
+// GetDateFromMilliseconds returns the date time from the milliseconds
+// provided.
+func GetDateFromMilliseconds(m uint64) time.Time {
+	return DefaultEpoch().GetDateFromMilliseconds(m)
+}
+
+// clampUnits returns v if there is no error, otherwise 0 if the date is before
+// the default epoch or the maximum m if after.
+func clampUnits(t time.Time, v uint64, err error, m uint64) uint64 {
+	if err == nil {
+		return v
+	}
+	if t.Before(DefaultEpoch().Base()) {
+		return 0
+	}
+	return m
 }
 
 // ReadDateFromUInt32 reads the date from the buffer where the date is stored
 // as the number of minutes as an unsigned 32 bit integer that have elapsed
-// since the IoDateBase epoch.
+// since the default epoch.
 func ReadDateFromUInt32(b *bytes.Buffer) (time.Time, error) {
-	i, err := ReadUint32(b)
-	if err != nil {
-		return time.Time{}, err
-	}
-	return IoDateBase.Add(time.Duration(i) * time.Minute), nil
+	return NewDecoder(b).ReadDateFromUInt32()
 }
 
 // WriteDateToUInt32 writes the date to the buffer as an unsigned 32 bit
-// representing the number of minutes that have elapsed since the IoDateBase
-// epoch.
+// representing the number of minutes that have elapsed since the default
+// epoch. Returns ErrDateOutOfRange if the date is before DefaultEpoch().Base()
+// or after DefaultEpoch().MaxDateMinutes().
 func WriteDateToUInt32(b *bytes.Buffer, t time.Time) error {
-	return WriteUint32(b, GetDateInMinutes(t))
+	return NewEncoder(b).WriteDateToUInt32(t)
 }
 
 // ReadByte reads the next byte from the buffer.
 func ReadByte(b *bytes.Buffer) (byte, error) {
-	d := b.Next(1)
-	if len(d) != 1 {
-		return 0, fmt.Errorf("'%d' bytes incorrect for byte", len(d))
-	}
-	return d[0], nil
+	return NewDecoder(b).ReadByte()
 }
 
 // WriteByte writes the byte provided to the buffer.
 func WriteByte(b *bytes.Buffer, i byte) error {
-	return b.WriteByte(i)
+	return NewEncoder(b).WriteByte(i)
 }
 
 // Read the next byte as a bool and returns the value.
 func ReadBool(b *bytes.Buffer) (bool, error) {
-	d := b.Next(1)
-	if len(d) != 1 {
-		return false, fmt.Errorf("'%d' bytes incorrect for bool", len(d))
-	}
-	return d[0] != 0, nil
+	return NewDecoder(b).ReadBool()
 }
 
 // WriteBool writes the boolean value to the buffer as a byte.
 func WriteBool(b *bytes.Buffer, v bool) error {
-	var d byte
-	if v {
-		d = 1
-	}
-	return b.WriteByte(d)
+	return NewEncoder(b).WriteBool(v)
 }
 
 // ReadUint32 reads an unsigned 32 bit integer from the buffer. The integer is
 // stored in little endian format.
 func ReadUint32(b *bytes.Buffer) (uint32, error) {
-	d := b.Next(4)
-	if len(d) != 4 {
-		return 0, fmt.Errorf("'%d' bytes incorrect for Uint32", len(d))
-	}
-	return binary.LittleEndian.Uint32(d), nil
+	return NewDecoder(b).ReadUint32()
 }
 
 // ReadUint16 reads an unsigned 16 bit integer from the buffer. The integer is
 // stored in little endian format.
 func ReadUint16(b *bytes.Buffer) (uint16, error) {
-	d := b.Next(2)
-	if len(d) != 2 {
-		return 0, fmt.Errorf("'%d' bytes incorrect for Uint16", len(d))
-	}
-	return binary.LittleEndian.Uint16(d), nil
+	return NewDecoder(b).ReadUint16()
 }
 
-// WriteUint16 reads an unsigned 16 bit integer from the buffer where the
+// WriteUint16 writes an unsigned 16 bit integer to the buffer where the
 // integer is stored in little endian format.
 func WriteUint16(b *bytes.Buffer, i uint16) error {
-	v := make([]byte, 2)
-	binary.LittleEndian.PutUint16(v, i)
-	l, err := b.Write(v)
-	if err == nil {
-		if l != len(v) {
-			return fmt.Errorf(
-				"Mismatched lengths '%d' and '%d'",
-				l,
-				len(v))
-		}
-	}
-	return err
+	return NewEncoder(b).WriteUint16(i)
 }
 
-// WriteUint32 reads an unsigned 32 bit integer from the buffer where the
+// WriteUint32 writes an unsigned 32 bit integer to the buffer where the
 // integer is stored in little endian format.
 func WriteUint32(b *bytes.Buffer, i uint32) error {
-	v := make([]byte, 4)
-	binary.LittleEndian.PutUint32(v, i)
-	l, err := b.Write(v)
-	if err == nil {
-		if l != len(v) {
-			return fmt.Errorf(
-				"mismatched lengths '%d' and '%d'",
-				l,
-				len(v))
-		}
-	}
-	return err
+	return NewEncoder(b).WriteUint32(i)
+}
+
+// ReadUint64 reads an unsigned 64 bit integer from the buffer. The integer is
+// stored in little endian format.
+func ReadUint64(b *bytes.Buffer) (uint64, error) {
+	return NewDecoder(b).ReadUint64()
+}
+
+// WriteUint64 writes an unsigned 64 bit integer to the buffer where the
+// integer is stored in little endian format.
+func WriteUint64(b *bytes.Buffer, i uint64) error {
+	return NewEncoder(b).WriteUint64(i)
+}
+
+// ReadInt16 reads a signed 16 bit integer from the buffer. The integer is
+// stored in little endian two's complement format.
+func ReadInt16(b *bytes.Buffer) (int16, error) {
+	return NewDecoder(b).ReadInt16()
+}
+
+// WriteInt16 writes a signed 16 bit integer to the buffer where the integer is
+// stored in little endian two's complement format.
+func WriteInt16(b *bytes.Buffer, i int16) error {
+	return NewEncoder(b).WriteInt16(i)
+}
+
+// ReadInt32 reads a signed 32 bit integer from the buffer. The integer is
+// stored in little endian two's complement format.
+func ReadInt32(b *bytes.Buffer) (int32, error) {
+	return NewDecoder(b).ReadInt32()
+}
+
+// WriteInt32 writes a signed 32 bit integer to the buffer where the integer is
+// stored in little endian two's complement format.
+func WriteInt32(b *bytes.Buffer, i int32) error {
+	return NewEncoder(b).WriteInt32(i)
+}
+
+// ReadInt64 reads a signed 64 bit integer from the buffer. The integer is
+// stored in little endian two's complement format.
+func ReadInt64(b *bytes.Buffer) (int64, error) {
+	return NewDecoder(b).ReadInt64()
+}
+
+// WriteInt64 writes a signed 64 bit integer to the buffer where the integer is
+// stored in little endian two's complement format.
+func WriteInt64(b *bytes.Buffer, i int64) error {
+	return NewEncoder(b).WriteInt64(i)
+}
+
+// ReadUvarint reads an unsigned integer stored as a varint from the buffer.
+func ReadUvarint(b *bytes.Buffer) (uint64, error) {
+	return NewDecoder(b).ReadUvarint()
+}
+
+// WriteUvarint writes an unsigned integer to the buffer as a varint using
+// between 1 and 10 bytes where small values use fewer bytes.
+func WriteUvarint(b *bytes.Buffer, i uint64) error {
+	return NewEncoder(b).WriteUvarint(i)
+}
+
+// ReadDateSeconds reads the date from the buffer where the date is stored as
+// the number of seconds as an unsigned 32 bit integer that have elapsed since
+// the default epoch.
+func ReadDateSeconds(b *bytes.Buffer) (time.Time, error) {
+	return NewDecoder(b).ReadDateSeconds()
+}
+
+// WriteDateSeconds writes the date to the buffer as an unsigned 32 bit integer
+// representing the number of seconds that have elapsed since the default
+// epoch. Returns ErrDateOutOfRange if the date can't be stored.
+func WriteDateSeconds(b *bytes.Buffer, t time.Time) error {
+	return NewEncoder(b).WriteDateSeconds(t)
+}
+
+// ReadDateHours reads the date from the buffer where the date is stored as the
+// number of hours as an unsigned 32 bit integer that have elapsed since the
+// default epoch.
+func ReadDateHours(b *bytes.Buffer) (time.Time, error) {
+	return NewDecoder(b).ReadDateHours()
+}
+
+// WriteDateHours writes the date to the buffer as an unsigned 32 bit integer
+// representing the number of hours that have elapsed since the default epoch.
+// Returns ErrDateOutOfRange if the date can't be stored.
+func WriteDateHours(b *bytes.Buffer, t time.Time) error {
+	return NewEncoder(b).WriteDateHours(t)
+}
+
+// ReadDateHours16 reads the date from the buffer where the date is stored as
+// the number of hours as an unsigned 16 bit integer that have elapsed since
+// the default epoch.
+func ReadDateHours16(b *bytes.Buffer) (time.Time, error) {
+	return NewDecoder(b).ReadDateHours16()
+}
+
+// WriteDateHours16 writes the date to the buffer as an unsigned 16 bit integer
+// representing the number of hours that have elapsed since the default epoch.
+// Returns ErrDateOutOfRange if the date can't be stored.
+func WriteDateHours16(b *bytes.Buffer, t time.Time) error {
+	return NewEncoder(b).WriteDateHours16(t)
+}
+
+// ReadDateMilliseconds reads the date from the buffer where the date is stored
+// as the number of milliseconds as an unsigned 64 bit integer that have
+// elapsed since the default epoch.
+func ReadDateMilliseconds(b *bytes.Buffer) (time.Time, error) {
+	return NewDecoder(b).ReadDateMilliseconds()
+}
+
+// WriteDateMilliseconds writes the date to the buffer as an unsigned 64 bit
+// integer representing the number of milliseconds that have elapsed since the
+// default epoch. Returns ErrDateOutOfRange if the date can't be stored.
+func WriteDateMilliseconds(b *bytes.Buffer, t time.Time) error {
+	return NewEncoder(b).WriteDateMilliseconds(t)
+}
+
+// ReadHeader reads a header written by WriteHeader. Returns ErrInvalidMagic if
+// the first byte is not IoHeaderMagic.
+func ReadHeader(b *bytes.Buffer) (Header, error) {
+	return NewDecoder(b).ReadHeader()
+}
+
+// WriteHeader writes IoHeaderMagic followed by the version and flags of the
+// header. Must be written before the payload.
+func WriteHeader(b *bytes.Buffer, h Header) error {
+	return NewEncoder(b).WriteHeader(h)
+}
+
+// ReadFrame reads a frame written by WriteFrame and returns the payload.
+// Returns ErrChecksum if the payload does not match the checksum.
+func ReadFrame(b *bytes.Buffer) ([]byte, error) {
+	return NewDecoder(b).ReadFrame()
+}
+
+// WriteFrame writes the length of the payload, the payload and its CRC32C
+// checksum.
+func WriteFrame(b *bytes.Buffer, p []byte) error {
+	return NewEncoder(b).WriteFrame(p)
+}
+
+// ReadCompressedByteArray reads a byte array written by
+// WriteCompressedByteArray and returns it decompressed. Returns
+// ErrLimitExceeded if the decompressed bytes exceed IoMaxDecompressedLength.
+func ReadCompressedByteArray(b *bytes.Buffer) ([]byte, error) {
+	return NewDecoder(b).ReadCompressedByteArray()
+}
+
+// WriteCompressedByteArray writes the compression algorithm followed by the
+// byte array compressed with it.
+func WriteCompressedByteArray(b *bytes.Buffer, v []byte, c Compression) error {
+	return NewEncoder(b).WriteCompressedByteArray(v, c)
 }
@@ -0,0 +1,211 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"testing"
+	"testing/iotest"
+)
+
+// fuzzOptions are the options each fuzz target reads the input with. The
+// Read* functions in io.go use the zero Options.
+var fuzzOptions = []Options{
+	{},
+	{Varint: true},
+	{
+		Varint:       true,
+		Canonical:    true,
+		ValidateUTF8: true,
+		Limits: Limits{
+			MaxLength: 64,
+			MaxCount:  8,
+			MaxBytes:  256,
+			MaxDepth:  2}},
+}
+
+// fuzzRead reads the input with r for each of the fuzzOptions, once from a
+// bytes.Buffer and once from a reader that returns a byte at a time so that
+// both the buffer fast paths and the stream paths are covered. The fuzzer
+// fails the test if r panics or only one of the reads succeeds. The seed
+// corpus is in testdata/fuzz.
+func fuzzRead[T any](f *testing.F, r func(d *Decoder) (T, error)) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, o := range fuzzOptions {
+			_, err := r(NewDecoderWithOptions(bytes.NewBuffer(data), o))
+			_, serr := r(NewDecoderWithOptions(
+				iotest.OneByteReader(bytes.NewReader(data)),
+				o))
+			if (err == nil) != (serr == nil) {
+				t.Fatalf("buffer error '%v' but stream error '%v'", err, serr)
+			}
+		}
+	})
+}
+
+func FuzzReadStrings(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadStrings)
+}
+
+func FuzzReadByteArrayArray(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadByteArrayArray)
+}
+
+func FuzzReadStringMap(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadStringMap)
+}
+
+func FuzzReadUint32s(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadUint32s)
+}
+
+func FuzzReadCount(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadCount)
+}
+
+func FuzzReadFloat32(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadFloat32)
+}
+
+func FuzzReadFloat64(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadFloat64)
+}
+
+func FuzzReadTime(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadTime)
+}
+
+func FuzzReadTimeCompact(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadTimeCompact)
+}
+
+func FuzzReadDate(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadDate)
+}
+
+func FuzzReadMarshaller(f *testing.F) {
+	fuzzRead(f, func(d *Decoder) (*testMarshaller, error) {
+		var m testMarshaller
+		return &m, d.ReadMarshaller(&m)
+	})
+}
+
+func FuzzReadString(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadString)
+}
+
+func FuzzReadStringL(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadStringL)
+}
+
+func FuzzReadByteArray(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadByteArray)
+}
+
+func FuzzReadByteArrayCopy(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadByteArrayCopy)
+}
+
+// FuzzReadByteArrayNoLength uses the first byte of the input as the signed
+// length to read so that negative lengths are also tried.
+func FuzzReadByteArrayNoLength(f *testing.F) {
+	fuzzRead(f, func(d *Decoder) ([]byte, error) {
+		l, err := d.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		return d.ReadByteArrayNoLength(int(int8(l)))
+	})
+}
+
+func FuzzReadByteArrayNoLengthCopy(f *testing.F) {
+	fuzzRead(f, func(d *Decoder) ([]byte, error) {
+		l, err := d.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		return d.ReadByteArrayNoLengthCopy(int(int8(l)))
+	})
+}
+
+func FuzzReadDateFromUInt32(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadDateFromUInt32)
+}
+
+func FuzzReadByte(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadByte)
+}
+
+func FuzzReadBool(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadBool)
+}
+
+func FuzzReadUint16(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadUint16)
+}
+
+func FuzzReadUint32(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadUint32)
+}
+
+func FuzzReadUint64(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadUint64)
+}
+
+func FuzzReadInt16(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadInt16)
+}
+
+func FuzzReadInt32(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadInt32)
+}
+
+func FuzzReadInt64(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadInt64)
+}
+
+func FuzzReadUvarint(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadUvarint)
+}
+
+func FuzzReadDateSeconds(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadDateSeconds)
+}
+
+func FuzzReadDateHours(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadDateHours)
+}
+
+func FuzzReadDateHours16(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadDateHours16)
+}
+
+func FuzzReadDateMilliseconds(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadDateMilliseconds)
+}
+
+func FuzzReadHeader(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadHeader)
+}
+
+func FuzzReadFrame(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadFrame)
+}
+
+func FuzzReadCompressedByteArray(f *testing.F) {
+	fuzzRead(f, (*Decoder).ReadCompressedByteArray)
+}
@@ -0,0 +1,451 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+// propertyOptions are the formats each round trip property is checked with.
+var propertyOptions = []Options{{}, {Varint: true}}
+
+// roundTrip writes v with w, reads it back with r and returns an error if the
+// value read differs or bytes remain.
+func roundTrip[T any](
+	o Options,
+	v T,
+	w func(*Encoder, T) error,
+	r func(*Decoder) (T, error)) error {
+	var b bytes.Buffer
+	err := w(NewEncoderWithOptions(&b, o), v)
+	if err != nil {
+		return err
+	}
+	a, err := r(NewDecoderWithOptions(&b, o))
+	if err != nil {
+		return err
+	}
+	if b.Len() != 0 {
+		return fmt.Errorf("'%d' bytes remain", b.Len())
+	}
+	if !propertyEqual(a, v) {
+		return fmt.Errorf("'%v' != '%v'", a, v)
+	}
+	return nil
+}
+
+// propertyEqual returns true if a and b are deeply equal treating nil and
+// empty slices and maps as the same, times as equal if they are the same
+// instant, and NaN as equal to NaN.
+func propertyEqual(a, b interface{}) bool {
+	if t, ok := a.(time.Time); ok {
+		return t.Equal(b.(time.Time))
+	}
+	x, y := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch x.Kind() {
+	case reflect.Slice, reflect.Map:
+		if x.Len() == 0 && y.Len() == 0 {
+			return true
+		}
+		if x.Kind() == reflect.Slice && x.Len() == y.Len() {
+			for i := 0; i < x.Len(); i++ {
+				if !propertyEqual(
+					x.Index(i).Interface(),
+					y.Index(i).Interface()) {
+					return false
+				}
+			}
+			return true
+		}
+	case reflect.Float32, reflect.Float64:
+		if math.IsNaN(x.Float()) && math.IsNaN(y.Float()) {
+			return true
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// checkRoundTrip checks the round trip of values generated by testing/quick
+// for each of the propertyOptions.
+func checkRoundTrip[T any](
+	t *testing.T,
+	w func(*Encoder, T) error,
+	r func(*Decoder) (T, error)) {
+	checkRoundTripOf(t, func(v T) T { return v }, w, r)
+}
+
+// checkRoundTripOf is the same as checkRoundTrip but the values are created
+// with f from the values generated by testing/quick, for example to create
+// dates from a number of units since the epoch. Values containing a zero byte
+// are skipped in the fixed format because null terminated strings can't
+// contain them.
+func checkRoundTripOf[U any, T any](
+	t *testing.T,
+	f func(U) T,
+	w func(*Encoder, T) error,
+	r func(*Decoder) (T, error)) {
+	for _, o := range propertyOptions {
+		err := quick.Check(func(u U) bool {
+			v := f(u)
+			if !o.Varint && strings.Contains(fmt.Sprint(v), "\x00") {
+				return true
+			}
+			err := roundTrip(o, v, w, r)
+			if err != nil {
+				t.Log(err)
+			}
+			return err == nil
+		}, nil)
+		if err != nil {
+			t.Fatalf("varint '%t': %v", o.Varint, err)
+		}
+	}
+}
+
+// TestIoRoundTripProperties verifies values generated by testing/quick round
+// trip through every Write* and Read* pair in both formats.
+func TestIoRoundTripProperties(t *testing.T) {
+	t.Run("string", func(t *testing.T) {
+		checkRoundTrip(t, (*Encoder).WriteString, (*Decoder).ReadString)
+	})
+	t.Run("stringl", func(t *testing.T) {
+		checkRoundTrip(t, (*Encoder).WriteStringL, (*Decoder).ReadStringL)
+	})
+	t.Run("strings", func(t *testing.T) {
+		checkRoundTrip(t, (*Encoder).WriteStrings, (*Decoder).ReadStrings)
+	})
+	t.Run("string map", func(t *testing.T) {
+		checkRoundTrip(
+			t,
+			(*Encoder).WriteStringMap,
+			(*Decoder).ReadStringMap)
+	})
+	t.Run("byte array", func(t *testing.T) {
+		checkRoundTrip(
+			t,
+			(*Encoder).WriteByteArray,
+			(*Decoder).ReadByteArrayCopy)
+	})
+	t.Run("byte array array", func(t *testing.T) {
+		checkRoundTrip(
+			t,
+			(*Encoder).WriteByteArrayArray,
+			(*Decoder).ReadByteArrayArray)
+	})
+	t.Run("compressed", func(t *testing.T) {
+		for _, c := range []Compression{CompressionNone, CompressionGzip} {
+			checkRoundTrip(
+				t,
+				func(e *Encoder, v []byte) error {
+					return e.WriteCompressedByteArray(v, c)
+				},
+				(*Decoder).ReadCompressedByteArray)
+		}
+	})
+	t.Run("frame", func(t *testing.T) {
+		checkRoundTrip(t, (*Encoder).WriteFrame, (*Decoder).ReadFrame)
+	})
+	t.Run("marshaller", func(t *testing.T) {
+		checkRoundTripOf(
+			t,
+			func(v string) *testMarshaller {
+				return &testMarshaller{value: v}
+			},
+			func(e *Encoder, m *testMarshaller) error {
+				return e.WriteMarshaller(m)
+			},
+			func(d *Decoder) (*testMarshaller, error) {
+				var m testMarshaller
+				return &m, d.ReadMarshaller(&m)
+			})
+	})
+	t.Run("header", func(t *testing.T) {
+		checkRoundTripOf(
+			t,
+			func(v [2]byte) Header {
+				return Header{Version: v[0], Flags: v[1]}
+			},
+			(*Encoder).WriteHeader,
+			(*Decoder).ReadHeader)
+	})
+	t.Run("bool", func(t *testing.T) {
+		checkRoundTrip(t, (*Encoder).WriteBool, (*Decoder).ReadBool)
+	})
+	t.Run("byte", func(t *testing.T) {
+		checkRoundTrip(t, (*Encoder).WriteByte, (*Decoder).ReadByte)
+	})
+	t.Run("uint16", func(t *testing.T) {
+		checkRoundTrip(t, (*Encoder).WriteUint16, (*Decoder).ReadUint16)
+	})
+	t.Run("uint32", func(t *testing.T) {
+		checkRoundTrip(t, (*Encoder).WriteUint32, (*Decoder).ReadUint32)
+	})
+	t.Run("uint32s", func(t *testing.T) {
+		checkRoundTrip(t, (*Encoder).WriteUint32s, (*Decoder).ReadUint32s)
+	})
+	t.Run("uint64", func(t *testing.T) {
+		checkRoundTrip(t, (*Encoder).WriteUint64, (*Decoder).ReadUint64)
+	})
+	t.Run("int16", func(t *testing.T) {
+		checkRoundTrip(t, (*Encoder).WriteInt16, (*Decoder).ReadInt16)
+	})
+	t.Run("int32", func(t *testing.T) {
+		checkRoundTrip(t, (*Encoder).WriteInt32, (*Decoder).ReadInt32)
+	})
+	t.Run("int64", func(t *testing.T) {
+		checkRoundTrip(t, (*Encoder).WriteInt64, (*Decoder).ReadInt64)
+	})
+	t.Run("float32", func(t *testing.T) {
+		checkRoundTrip(t, (*Encoder).WriteFloat32, (*Decoder).ReadFloat32)
+	})
+	t.Run("float64", func(t *testing.T) {
+		checkRoundTrip(t, (*Encoder).WriteFloat64, (*Decoder).ReadFloat64)
+	})
+	t.Run("uvarint", func(t *testing.T) {
+		checkRoundTrip(t, (*Encoder).WriteUvarint, (*Decoder).ReadUvarint)
+	})
+	t.Run("count", func(t *testing.T) {
+		checkRoundTripOf(
+			t,
+			func(v uint16) int { return int(v) },
+			(*Encoder).WriteCount,
+			(*Decoder).ReadCount)
+	})
+	t.Run("time", func(t *testing.T) {
+		checkRoundTripOf(
+			t,
+			propertyTime,
+			(*Encoder).WriteTime,
+			(*Decoder).ReadTime)
+	})
+	t.Run("time compact", func(t *testing.T) {
+		checkRoundTripOf(
+			t,
+			propertyTime,
+			(*Encoder).WriteTimeCompact,
+			(*Decoder).ReadTimeCompact)
+	})
+	t.Run("date", func(t *testing.T) {
+		checkRoundTripOf(
+			t,
+			propertyEpoch.GetDateFromDays,
+			(*Encoder).WriteDate,
+			(*Decoder).ReadDate)
+	})
+	t.Run("date minutes", func(t *testing.T) {
+		checkRoundTripOf(
+			t,
+			propertyEpoch.GetDateFromMinutes,
+			(*Encoder).WriteDateToUInt32,
+			(*Decoder).ReadDateFromUInt32)
+	})
+	t.Run("date seconds", func(t *testing.T) {
+		checkRoundTripOf(
+			t,
+			propertyEpoch.GetDateFromSeconds,
+			(*Encoder).WriteDateSeconds,
+			(*Decoder).ReadDateSeconds)
+	})
+	t.Run("date hours", func(t *testing.T) {
+		checkRoundTripOf(
+			t,
+			propertyEpoch.GetDateFromHours,
+			(*Encoder).WriteDateHours,
+			(*Decoder).ReadDateHours)
+	})
+	t.Run("date hours16", func(t *testing.T) {
+		checkRoundTripOf(
+			t,
+			func(v uint16) time.Time {
+				return propertyEpoch.GetDateFromHours(uint32(v))
+			},
+			(*Encoder).WriteDateHours16,
+			(*Decoder).ReadDateHours16)
+	})
+	t.Run("date milliseconds", func(t *testing.T) {
+		checkRoundTripOf(
+			t,
+			func(v uint64) time.Time {
+				return propertyEpoch.GetDateFromMilliseconds(
+					v % (IoDateMaxMilliseconds + 1))
+			},
+			(*Encoder).WriteDateMilliseconds,
+			(*Decoder).ReadDateMilliseconds)
+	})
+}
+
+// propertyEpoch is the epoch used to create dates from generated units.
+var propertyEpoch = DefaultEpoch()
+
+// propertyTime returns a time from the generated values with a zone offset
+// of whole minutes so that it can be written by WriteTimeCompact. An offset of
+// -1 minute is reserved by time.Time.MarshalBinary for UTC so can't be written
+// by WriteTime.
+func propertyTime(v [3]int64) time.Time {
+	m := int(v[2] % (14 * 60))
+	if m == -1 {
+		m = 0
+	}
+	z := time.FixedZone("", m*60)
+	return time.Unix(v[0], v[1]%int64(time.Second)).In(z)
+}
+
+// TestIoRoundTripEdges verifies the round trip of edge values in both formats
+// including empty values, arrays of the maximum length, the epoch boundaries
+// and leap days.
+func TestIoRoundTripEdges(t *testing.T) {
+	leap := []time.Time{
+		time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2096, time.February, 29, 0, 0, 0, 0, time.UTC),
+		time.Date(2100, time.March, 1, 0, 0, 0, 0, time.UTC),
+	}
+	dates := []struct {
+		name string
+		max  time.Time
+		w    func(*Encoder, time.Time) error
+		r    func(*Decoder) (time.Time, error)
+	}{
+		{
+			"date",
+			DefaultEpoch().MaxDate(),
+			(*Encoder).WriteDate,
+			(*Decoder).ReadDate},
+		{
+			"minutes",
+			DefaultEpoch().MaxDateMinutes(),
+			(*Encoder).WriteDateToUInt32,
+			(*Decoder).ReadDateFromUInt32},
+		{
+			"seconds",
+			propertyEpoch.GetDateFromSeconds(IoDateMaxSeconds),
+			(*Encoder).WriteDateSeconds,
+			(*Decoder).ReadDateSeconds},
+		{
+			"hours",
+			propertyEpoch.GetDateFromHours(IoDateMaxHours),
+			(*Encoder).WriteDateHours,
+			(*Decoder).ReadDateHours},
+		{
+			"hours16",
+			propertyEpoch.GetDateFromHours(IoDateMaxHours16),
+			(*Encoder).WriteDateHours16,
+			(*Decoder).ReadDateHours16},
+		{
+			"milliseconds",
+			propertyEpoch.GetDateFromMilliseconds(IoDateMaxMilliseconds),
+			(*Encoder).WriteDateMilliseconds,
+			(*Decoder).ReadDateMilliseconds},
+	}
+	for _, o := range propertyOptions {
+		t.Run(fmt.Sprintf("varint %t", o.Varint), func(t *testing.T) {
+			for _, d := range dates {
+				v := []time.Time{DefaultEpoch().Base(), d.max}
+				for _, l := range leap {
+					if !l.After(d.max) {
+						v = append(v, l)
+					}
+				}
+				for _, i := range v {
+					if err := roundTrip(o, i, d.w, d.r); err != nil {
+						t.Fatalf("%s '%s': %v", d.name, i, err)
+					}
+				}
+			}
+			for _, i := range leap {
+				err := roundTrip(
+					o,
+					i,
+					(*Encoder).WriteTimeCompact,
+					(*Decoder).ReadTimeCompact)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			testRoundTripEdges(t, o)
+		})
+	}
+}
+
+func testRoundTripEdges(t *testing.T, o Options) {
+	if err := roundTrip(
+		o,
+		"",
+		(*Encoder).WriteString,
+		(*Decoder).ReadString); err != nil {
+		t.Fatal(err)
+	}
+	if err := roundTrip(
+		o,
+		"",
+		(*Encoder).WriteStringL,
+		(*Decoder).ReadStringL); err != nil {
+		t.Fatal(err)
+	}
+	if err := roundTrip(
+		o,
+		[]byte{},
+		(*Encoder).WriteByteArray,
+		(*Decoder).ReadByteArray); err != nil {
+		t.Fatal(err)
+	}
+	if err := roundTrip(
+		o,
+		make([]string, math.MaxUint16),
+		(*Encoder).WriteStrings,
+		(*Decoder).ReadStrings); err != nil {
+		t.Fatal(err)
+	}
+	if err := roundTrip(
+		o,
+		make([][]byte, math.MaxUint16),
+		(*Encoder).WriteByteArrayArray,
+		(*Decoder).ReadByteArrayArray); err != nil {
+		t.Fatal(err)
+	}
+	if err := roundTrip(
+		o,
+		make([]uint32, math.MaxUint16),
+		(*Encoder).WriteUint32s,
+		(*Decoder).ReadUint32s); err != nil {
+		t.Fatal(err)
+	}
+
+	// Byte arrays and strings of exactly the MaxLength limit round trip.
+	o.Limits.MaxLength = 1024
+	if err := roundTrip(
+		o,
+		bytes.Repeat([]byte{0xff}, 1024),
+		(*Encoder).WriteByteArray,
+		(*Decoder).ReadByteArray); err != nil {
+		t.Fatal(err)
+	}
+	if err := roundTrip(
+		o,
+		strings.Repeat("A", 1024),
+		(*Encoder).WriteStringL,
+		(*Decoder).ReadStringL); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -18,7 +18,10 @@ package common
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"testing"
 	"time"
 )
@@ -41,3 +44,597 @@ func TestIoTime(t *testing.T) {
 	}
 	TestCompareDate(t, r, d)
 }
+
+// TestIoNumbers verifies the round trip of the 64 bit, signed and float64
+// primitives including their edge values.
+func TestIoNumbers(t *testing.T) {
+	t.Run("uint64", func(t *testing.T) {
+		for _, v := range []uint64{0, 1, math.MaxUint32 + 1, math.MaxUint64} {
+			var b bytes.Buffer
+			if err := WriteUint64(&b, v); err != nil {
+				t.Fatal(err)
+			}
+			if b.Len() != 8 {
+				t.Fatalf("wrote '%d' bytes", b.Len())
+			}
+			r, err := ReadUint64(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r != v {
+				t.Fatalf("'%d' != '%d'", r, v)
+			}
+		}
+	})
+	t.Run("int16", func(t *testing.T) {
+		for _, v := range []int16{0, -1, math.MinInt16, math.MaxInt16} {
+			var b bytes.Buffer
+			if err := WriteInt16(&b, v); err != nil {
+				t.Fatal(err)
+			}
+			r, err := ReadInt16(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r != v {
+				t.Fatalf("'%d' != '%d'", r, v)
+			}
+		}
+	})
+	t.Run("int32", func(t *testing.T) {
+		for _, v := range []int32{0, -1, math.MinInt32, math.MaxInt32} {
+			var b bytes.Buffer
+			if err := WriteInt32(&b, v); err != nil {
+				t.Fatal(err)
+			}
+			r, err := ReadInt32(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r != v {
+				t.Fatalf("'%d' != '%d'", r, v)
+			}
+		}
+	})
+	t.Run("int64", func(t *testing.T) {
+		for _, v := range []int64{0, -1, math.MinInt64, math.MaxInt64} {
+			var b bytes.Buffer
+			if err := WriteInt64(&b, v); err != nil {
+				t.Fatal(err)
+			}
+			r, err := ReadInt64(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r != v {
+				t.Fatalf("'%d' != '%d'", r, v)
+			}
+		}
+	})
+	t.Run("float64", func(t *testing.T) {
+		for _, v := range []float64{
+			0,
+			-1.5,
+			math.Pi,
+			math.MaxFloat64,
+			math.SmallestNonzeroFloat64,
+			math.Inf(-1)} {
+			var b bytes.Buffer
+			if err := WriteFloat64(&b, v); err != nil {
+				t.Fatal(err)
+			}
+			r, err := ReadFloat64(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r != v {
+				t.Fatalf("'%f' != '%f'", r, v)
+			}
+		}
+	})
+	t.Run("little endian", func(t *testing.T) {
+		var b bytes.Buffer
+		if err := WriteInt32(&b, -2); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b.Bytes(), []byte{0xFE, 0xFF, 0xFF, 0xFF}) {
+			t.Fatalf("unexpected bytes '%x'", b.Bytes())
+		}
+	})
+	t.Run("short", func(t *testing.T) {
+		b := bytes.NewBuffer([]byte{1, 2, 3})
+		if _, err := ReadInt64(b); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+// TestIoTooLong verifies that writers return ErrTooLong rather than writing a
+// truncated count or length.
+func TestIoTooLong(t *testing.T) {
+	t.Run("strings", func(t *testing.T) {
+		var b bytes.Buffer
+		err := WriteStrings(&b, make([]string, 70000))
+		testTooLong(t, err, 70000, math.MaxUint16)
+		if b.Len() != 0 {
+			t.Fatal("bytes written")
+		}
+	})
+	t.Run("byte array array", func(t *testing.T) {
+		var b bytes.Buffer
+		err := WriteByteArrayArray(&b, make([][]byte, math.MaxUint16+1))
+		testTooLong(t, err, math.MaxUint16+1, math.MaxUint16)
+	})
+	t.Run("max count", func(t *testing.T) {
+		var b bytes.Buffer
+		e := NewEncoderWithOptions(&b, Options{Limits: Limits{MaxCount: 2}})
+		if err := e.WriteStrings([]string{"A", "B"}); err != nil {
+			t.Fatal(err)
+		}
+		testTooLong(t, e.WriteStrings([]string{"A", "B", "C"}), 3, 2)
+	})
+	t.Run("max length", func(t *testing.T) {
+		for _, o := range []Options{
+			{Limits: Limits{MaxLength: 4}},
+			{Varint: true, Limits: Limits{MaxLength: 4}}} {
+			var b bytes.Buffer
+			e := NewEncoderWithOptions(&b, o)
+			if err := e.WriteByteArray([]byte{1, 2, 3, 4}); err != nil {
+				t.Fatal(err)
+			}
+			testTooLong(t, e.WriteByteArray([]byte{1, 2, 3, 4, 5}), 5, 4)
+			testTooLong(t, e.WriteString("ABCDE"), 5, 4)
+		}
+	})
+}
+
+func testTooLong(t *testing.T, err error, length int64, limit int64) {
+	var e *ErrTooLong
+	if !errors.As(err, &e) {
+		t.Fatalf("expected ErrTooLong but got '%v'", err)
+	}
+	if e.Length != length || e.Limit != limit {
+		t.Fatalf("unexpected length '%d' or limit '%d'", e.Length, e.Limit)
+	}
+}
+
+// TestIoTruncated verifies that cut off data results in ErrTruncated with the
+// expected and available byte counts rather than a partial value.
+func TestIoTruncated(t *testing.T) {
+	t.Run("byte array", func(t *testing.T) {
+		var b bytes.Buffer
+		if err := WriteByteArray(&b, []byte{1, 2, 3, 4, 5}); err != nil {
+			t.Fatal(err)
+		}
+		c := bytes.NewBuffer(b.Bytes()[:b.Len()-2])
+		v, err := ReadByteArray(c)
+		if v != nil {
+			t.Fatal("partial value returned")
+		}
+		testTruncated(t, err, 5, 3)
+	})
+	t.Run("byte array no length", func(t *testing.T) {
+		_, err := ReadByteArrayNoLength(bytes.NewBuffer([]byte{1}), 2)
+		testTruncated(t, err, 2, 1)
+	})
+	t.Run("string", func(t *testing.T) {
+		_, err := ReadString(bytes.NewBufferString("ABC"))
+		testTruncated(t, err, 4, 3)
+	})
+	t.Run("string empty", func(t *testing.T) {
+		_, err := ReadString(&bytes.Buffer{})
+		if err != io.EOF {
+			t.Fatalf("expected EOF but got '%v'", err)
+		}
+	})
+	t.Run("varint string", func(t *testing.T) {
+		var b bytes.Buffer
+		o := Options{Varint: true}
+		if err := NewEncoderWithOptions(&b, o).WriteString("ABC"); err != nil {
+			t.Fatal(err)
+		}
+		c := bytes.NewBuffer(b.Bytes()[:b.Len()-1])
+		_, err := NewDecoderWithOptions(c, o).ReadString()
+		testTruncated(t, err, 3, 2)
+	})
+	t.Run("uint32", func(t *testing.T) {
+		_, err := ReadUint32(bytes.NewBuffer([]byte{1, 2}))
+		testTruncated(t, err, 4, 2)
+	})
+}
+
+func testTruncated(t *testing.T, err error, expected int64, available int64) {
+	var e *ErrTruncated
+	if !errors.As(err, &e) {
+		t.Fatalf("expected ErrTruncated but got '%v'", err)
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatal("expected unexpected EOF")
+	}
+	if e.Expected != expected || e.Available != available {
+		t.Fatalf(
+			"unexpected expected '%d' or available '%d'",
+			e.Expected,
+			e.Available)
+	}
+}
+
+// TestIoTimeErrors verifies that a corrupt time results in an error rather
+// than the zero time.
+func TestIoTimeErrors(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteByteArray(&b, []byte{99, 1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadTime(&b); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// TestIoTimeCompact verifies the compact time encoding round trips the instant
+// and the zone offset using 14 bytes.
+func TestIoTimeCompact(t *testing.T) {
+	for _, v := range []time.Time{
+		time.Date(2022, time.March, 4, 5, 6, 7, 8, time.UTC),
+		time.Date(1969, time.December, 31, 23, 59, 59, 999999999, time.UTC),
+		time.Date(2022, time.March, 4, 5, 6, 7, 8, time.FixedZone("", -5*3600)),
+		time.Date(2022, time.March, 4, 5, 6, 7, 8, time.FixedZone("", 19800))} {
+		var b bytes.Buffer
+		if err := WriteTimeCompact(&b, v); err != nil {
+			t.Fatal(err)
+		}
+		if b.Len() != 14 {
+			t.Fatalf("wrote '%d' bytes", b.Len())
+		}
+		r, err := ReadTimeCompact(&b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r.Equal(v) {
+			t.Fatalf("'%s' != '%s'", r, v)
+		}
+		_, ro := r.Zone()
+		_, vo := v.Zone()
+		if ro != vo {
+			t.Fatalf("offset '%d' != '%d'", ro, vo)
+		}
+	}
+	t.Run("invalid offset", func(t *testing.T) {
+		var b bytes.Buffer
+		v := time.Date(1900, time.January, 1, 0, 0, 0, 0, time.FixedZone("", 75))
+		if err := WriteTimeCompact(&b, v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("invalid nanoseconds", func(t *testing.T) {
+		var b bytes.Buffer
+		if err := WriteInt64(&b, 0); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteUint32(&b, uint32(time.Second)); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteInt16(&b, 0); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadTimeCompact(&b); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+// TestIoDateRange verifies that dates outside the representable range return
+// ErrDateOutOfRange and that the boundary dates round trip.
+func TestIoDateRange(t *testing.T) {
+	t.Run("days", func(t *testing.T) {
+		testDateRange(
+			t,
+			WriteDate,
+			ReadDate,
+			DefaultEpoch().MaxDate(),
+			24*time.Hour)
+	})
+	t.Run("minutes", func(t *testing.T) {
+		testDateRange(
+			t,
+			WriteDateToUInt32,
+			ReadDateFromUInt32,
+			DefaultEpoch().MaxDateMinutes(),
+			time.Minute)
+	})
+	t.Run("get minutes", func(t *testing.T) {
+		if GetDateInMinutes(DefaultEpoch().Base().Add(-time.Minute)) != 0 {
+			t.Fatal("expected zero before min")
+		}
+		if GetDateInMinutes(DefaultEpoch().MaxDateMinutes().AddDate(1, 0, 0)) !=
+			IoDateMaxMinutes {
+			t.Fatal("expected max after max")
+		}
+		d := time.Date(2500, time.June, 1, 12, 30, 0, 0, time.UTC)
+		if !GetDateFromMinutes(GetDateInMinutes(d)).Equal(d) {
+			t.Fatal("date beyond time.Duration range")
+		}
+	})
+}
+
+func testDateRange(
+	t *testing.T,
+	write func(*bytes.Buffer, time.Time) error,
+	read func(*bytes.Buffer) (time.Time, error),
+	max time.Time,
+	unit time.Duration) {
+	for _, d := range []time.Time{DefaultEpoch().Base(), max} {
+		var b bytes.Buffer
+		if err := write(&b, d); err != nil {
+			t.Fatal(err)
+		}
+		r, err := read(&b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r.Equal(d) {
+			t.Fatalf("'%s' != '%s'", r, d)
+		}
+	}
+	base := DefaultEpoch().Base()
+	for _, d := range []time.Time{base.Add(-time.Nanosecond), max.Add(unit)} {
+		var b bytes.Buffer
+		var e *ErrDateOutOfRange
+		if err := write(&b, d); !errors.As(err, &e) {
+			t.Fatalf("expected ErrDateOutOfRange but got '%v'", err)
+		}
+		if !e.Max.Equal(max) {
+			t.Fatalf("max '%s' != '%s'", e.Max, max)
+		}
+		if b.Len() != 0 {
+			t.Fatal("bytes written")
+		}
+	}
+}
+
+// TestIoByteArrayAliasing verifies the zero-copy readers return slices that
+// reference the buffer's storage and change when the buffer is reused, while
+// the copying readers return slices that are unaffected.
+func TestIoByteArrayAliasing(t *testing.T) {
+	v := []byte{1, 2, 3, 4}
+	readers := []struct {
+		name  string
+		read  func(*bytes.Buffer) ([]byte, error)
+		alias bool
+	}{
+		{"ReadByteArray", ReadByteArray, true},
+		{"ReadByteArrayCopy", ReadByteArrayCopy, false},
+		{"ReadByteArrayNoLength", func(b *bytes.Buffer) ([]byte, error) {
+			return ReadByteArrayNoLength(b, len(v)+4)
+		}, true},
+		{"ReadByteArrayNoLengthCopy", func(b *bytes.Buffer) ([]byte, error) {
+			return ReadByteArrayNoLengthCopy(b, len(v)+4)
+		}, false},
+	}
+	for _, r := range readers {
+		t.Run(r.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := WriteByteArray(&b, v); err != nil {
+				t.Fatal(err)
+			}
+			a, err := r.read(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w := append([]byte(nil), a...)
+
+			// Reuse the buffer overwriting the storage the length and bytes
+			// were read from.
+			b.Reset()
+			b.Write(bytes.Repeat([]byte{0xff}, len(v)+4))
+			if r.alias == bytes.Equal(a, w) {
+				t.Fatalf("aliasing '%v' not as expected", r.alias)
+			}
+		})
+	}
+	t.Run("reader", func(t *testing.T) {
+		var b bytes.Buffer
+		if err := WriteByteArray(&b, v); err != nil {
+			t.Fatal(err)
+		}
+		s := b.Bytes()
+		a, err := NewDecoder(bytes.NewReader(s)).ReadByteArray()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range s {
+			s[i] = 0xff
+		}
+		if !bytes.Equal(a, v) {
+			t.Fatal("slice read from other readers must not alias the source")
+		}
+	})
+}
+
+// TestIoStringNull verifies null terminated strings containing a zero byte are
+// rejected without writing any bytes while length prefixed strings preserve
+// them.
+func TestIoStringNull(t *testing.T) {
+	s := "A\x00B"
+	var b bytes.Buffer
+	err := WriteString(&b, s)
+	var e *ErrEmbeddedNull
+	if !errors.As(err, &e) || e.Index != 1 {
+		t.Fatalf("expected ErrEmbeddedNull but got '%v'", err)
+	}
+	if b.Len() != 0 {
+		t.Fatal("bytes written")
+	}
+	for _, v := range []string{s, "", "\x00", "Hello"} {
+		b.Reset()
+		if err := WriteStringL(&b, v); err != nil {
+			t.Fatal(err)
+		}
+		if b.Len() != len(v)+4 {
+			t.Fatalf("length '%d' not '%d'", b.Len(), len(v)+4)
+		}
+		r, err := ReadStringL(&b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r != v {
+			t.Fatalf("'%q' != '%q'", r, v)
+		}
+	}
+	t.Run("varint", func(t *testing.T) {
+		var b bytes.Buffer
+		o := Options{Varint: true}
+		if err := NewEncoderWithOptions(&b, o).WriteString(s); err != nil {
+			t.Fatal(err)
+		}
+		r, err := NewDecoderWithOptions(&b, o).ReadStringL()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r != s {
+			t.Fatalf("'%q' != '%q'", r, s)
+		}
+	})
+	t.Run("truncated", func(t *testing.T) {
+		var b bytes.Buffer
+		if err := WriteStringL(&b, "Hello"); err != nil {
+			t.Fatal(err)
+		}
+		b.Truncate(b.Len() - 1)
+		_, err := ReadStringL(&b)
+		var e *ErrTruncated
+		if !errors.As(err, &e) {
+			t.Fatalf("expected ErrTruncated but got '%v'", err)
+		}
+	})
+}
+
+// TestIoStringUTF8 verifies invalid UTF-8 is only rejected when the
+// ValidateUTF8 option is set.
+func TestIoStringUTF8(t *testing.T) {
+	s := "AB\xffC"
+	for _, varint := range []bool{false, true} {
+		t.Run(fmt.Sprintf("varint %v", varint), func(t *testing.T) {
+			o := Options{Varint: varint}
+			v := Options{Varint: varint, ValidateUTF8: true}
+			var e *ErrInvalidUTF8
+			var b bytes.Buffer
+			for _, w := range []func(*Encoder) error{
+				func(e *Encoder) error { return e.WriteString(s) },
+				func(e *Encoder) error { return e.WriteStringL(s) },
+			} {
+				err := w(NewEncoderWithOptions(&b, v))
+				if !errors.As(err, &e) || e.Index != 2 {
+					t.Fatalf("expected ErrInvalidUTF8 but got '%v'", err)
+				}
+				if err := w(NewEncoderWithOptions(&b, o)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			d := b.Bytes()
+			r := NewDecoderWithOptions(bytes.NewBuffer(d), v)
+			for _, f := range []func() (string, error){
+				r.ReadString,
+				r.ReadStringL,
+			} {
+				_, err := f()
+				if !errors.As(err, &e) || e.Index != 2 {
+					t.Fatalf("expected ErrInvalidUTF8 but got '%v'", err)
+				}
+			}
+			r = NewDecoderWithOptions(bytes.NewBuffer(d), o)
+			for _, f := range []func() (string, error){
+				r.ReadString,
+				r.ReadStringL,
+			} {
+				a, err := f()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if a != s {
+					t.Fatalf("'%q' != '%q'", a, s)
+				}
+			}
+		})
+	}
+}
+
+// TestIoStringMap verifies maps round trip and that the bytes do not depend on
+// the order the map was populated in.
+func TestIoStringMap(t *testing.T) {
+	a := map[string]string{"b": "2", "a": "1", "c": ""}
+	c := make(map[string]string)
+	for _, k := range []string{"c", "a", "b"} {
+		c[k] = a[k]
+	}
+	var x, y bytes.Buffer
+	if err := WriteStringMap(&x, a); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteStringMap(&y, c); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(x.Bytes(), y.Bytes()) {
+		t.Fatal("map bytes depend on insertion order")
+	}
+	var w bytes.Buffer
+	for _, v := range []string{"a", "1", "b", "2", "c", ""} {
+		if err := WriteString(&w, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(x.Bytes()[2:], w.Bytes()) {
+		t.Fatal("keys not in ascending order")
+	}
+	r, err := ReadStringMap(&x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(r) != fmt.Sprint(a) {
+		t.Fatalf("'%v' != '%v'", r, a)
+	}
+	t.Run("duplicate key", func(t *testing.T) {
+		var b bytes.Buffer
+		if err := WriteCount(&b, 2); err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range []string{"a", "1", "a", "2"} {
+			if err := WriteString(&b, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := ReadStringMap(&b); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+// TestIoUint32s verifies integer slices round trip including empty slices.
+func TestIoUint32s(t *testing.T) {
+	for _, v := range [][]uint32{{}, {0}, {1, math.MaxUint32, 3}} {
+		var b bytes.Buffer
+		if err := WriteUint32s(&b, v); err != nil {
+			t.Fatal(err)
+		}
+		if b.Len() != 2+len(v)*4 {
+			t.Fatalf("length '%d' incorrect", b.Len())
+		}
+		r, err := ReadUint32s(&b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(r) != fmt.Sprint(v) {
+			t.Fatalf("'%v' != '%v'", r, v)
+		}
+	}
+	var b bytes.Buffer
+	if err := WriteUint32s(&b, []uint32{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	b.Truncate(b.Len() - 1)
+	_, err := ReadUint32s(&b)
+	var e *ErrTruncated
+	if !errors.As(err, &e) {
+		t.Fatalf("expected ErrTruncated but got '%v'", err)
+	}
+}
@@ -0,0 +1,254 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ToJSON returns a JSON object containing the fields of the schema read from
+// the data in schema order. Times and dates are RFC 3339 strings, byte arrays
+// are base64 strings, string maps are objects and lists are arrays. 64-bit
+// integers are decimal strings as JavaScript numbers can't represent them
+// exactly, and NaN and infinite floats are the strings "NaN", "+Inf" and
+// "-Inf" as JSON has no numbers for them. Returns an error if the data can't
+// be read or bytes remain after the last field. Returns ErrInvalidUTF8 if a
+// string isn't valid UTF-8 as it couldn't be converted back to the same bytes.
+func ToJSON(s Schema, data []byte) ([]byte, error) {
+	return ToJSONWithOptions(s, data, Options{})
+}
+
+// ToJSONWithOptions is the same as ToJSON but reads the data with the options
+// provided.
+func ToJSONWithOptions(s Schema, data []byte, o Options) ([]byte, error) {
+	b := bytes.NewBuffer(data)
+	d := NewDecoderWithOptions(b, o)
+	var j bytes.Buffer
+	j.WriteByte('{')
+	for i, f := range s {
+		v, err := f.read(d)
+		if err != nil {
+			return nil, fmt.Errorf("field '%s': %w", f.Name, err)
+		}
+		if i > 0 {
+			j.WriteByte(',')
+		}
+		err = writeJSON(&j, f.Name, v)
+		if err != nil {
+			return nil, fmt.Errorf("field '%s': %w", f.Name, err)
+		}
+	}
+	j.WriteByte('}')
+	if b.Len() > 0 {
+		return nil, fmt.Errorf("'%d' bytes remain after last field", b.Len())
+	}
+	return j.Bytes(), nil
+}
+
+// FromJSON returns the binary data for the JSON object in the form returned by
+// ToJSON. Every field in the schema must be present in the object and the
+// object must not contain other members. 64-bit integers and floats can be
+// numbers or strings. Used to author test fixtures as JSON
+// and convert them to the exact bytes.
+func FromJSON(s Schema, data []byte) ([]byte, error) {
+	return FromJSONWithOptions(s, data, Options{})
+}
+
+// FromJSONWithOptions is the same as FromJSON but writes the data with the
+// options provided.
+func FromJSONWithOptions(s Schema, data []byte, o Options) ([]byte, error) {
+	var m map[string]json.RawMessage
+	err := json.Unmarshal(data, &m)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	e := NewEncoderWithOptions(&b, o)
+	for _, f := range s {
+		r, ok := m[f.Name]
+		if !ok {
+			return nil, fmt.Errorf("field '%s' missing", f.Name)
+		}
+		delete(m, f.Name)
+		v, err := f.readJSON(r)
+		if err != nil {
+			return nil, fmt.Errorf("field '%s': %w", f.Name, err)
+		}
+		err = f.write(e, v)
+		if err != nil {
+			return nil, fmt.Errorf("field '%s': %w", f.Name, err)
+		}
+	}
+	for n := range m {
+		return nil, fmt.Errorf("field '%s' not in schema", n)
+	}
+	return b.Bytes(), nil
+}
+
+// writeJSON writes the name and value as a JSON object member to b.
+func writeJSON(b *bytes.Buffer, n string, v interface{}) error {
+	k, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	err = validateJSON(v)
+	if err != nil {
+		return err
+	}
+	j, err := json.Marshal(jsonValue(v))
+	if err != nil {
+		return err
+	}
+	b.Write(k)
+	b.WriteByte(':')
+	b.Write(j)
+	return nil
+}
+
+// validateJSON returns ErrInvalidUTF8 if v is or contains a string that isn't
+// valid UTF-8. json.Marshal would replace the invalid bytes with U+FFFD so
+// FromJSON wouldn't return the original bytes.
+func validateJSON(v interface{}) error {
+	var s []string
+	switch v := v.(type) {
+	case string:
+		s = []string{v}
+	case []string:
+		s = v
+	case map[string]string:
+		for k, i := range v {
+			s = append(s, k, i)
+		}
+	}
+	for _, i := range s {
+		if n := invalidUTF8(i); n >= 0 {
+			return &ErrInvalidUTF8{Index: n}
+		}
+	}
+	return nil
+}
+
+// jsonValue returns the value to marshal to JSON for v. 64-bit integers and
+// floats that aren't finite are returned as strings.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float32:
+		if !isFinite(float64(v)) {
+			return strconv.FormatFloat(float64(v), 'g', -1, 32)
+		}
+	case float64:
+		if !isFinite(v) {
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		}
+	}
+	return v
+}
+
+// isFinite returns true if f is neither NaN nor infinite.
+func isFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// readJSON returns the value of the field from the JSON with the same type as
+// the value returned by read.
+func (f Field) readJSON(r json.RawMessage) (interface{}, error) {
+	switch f.Type {
+	case FieldString, FieldStringL:
+		return unmarshalJSON[string](r)
+	case FieldStrings:
+		return unmarshalJSON[[]string](r)
+	case FieldStringMap:
+		return unmarshalJSON[map[string]string](r)
+	case FieldByteArray, FieldFixed:
+		return unmarshalJSON[[]byte](r)
+	case FieldByteArrayArray:
+		return unmarshalJSON[[][]byte](r)
+	case FieldBool:
+		return unmarshalJSON[bool](r)
+	case FieldByte:
+		return unmarshalJSON[byte](r)
+	case FieldUint16:
+		return unmarshalJSON[uint16](r)
+	case FieldUint32:
+		return unmarshalJSON[uint32](r)
+	case FieldUint32s:
+		return unmarshalJSON[[]uint32](r)
+	case FieldUint64, FieldUvarint:
+		return unmarshalJSONString(r, func(s string) (uint64, error) {
+			return strconv.ParseUint(s, 10, 64)
+		})
+	case FieldInt16:
+		return unmarshalJSON[int16](r)
+	case FieldInt32:
+		return unmarshalJSON[int32](r)
+	case FieldInt64:
+		return unmarshalJSONString(r, func(s string) (int64, error) {
+			return strconv.ParseInt(s, 10, 64)
+		})
+	case FieldFloat32:
+		return unmarshalJSONString(r, func(s string) (float32, error) {
+			f, err := strconv.ParseFloat(s, 32)
+			return float32(f), err
+		})
+	case FieldFloat64:
+		return unmarshalJSONString(r, func(s string) (float64, error) {
+			return strconv.ParseFloat(s, 64)
+		})
+	case FieldTime,
+		FieldTimeCompact,
+		FieldDate,
+		FieldDateMinutes,
+		FieldDateSeconds,
+		FieldDateHours,
+		FieldDateHours16,
+		FieldDateMilliseconds:
+		return unmarshalJSON[time.Time](r)
+	}
+	return nil, f.validate()
+}
+
+// unmarshalJSON returns the JSON unmarshalled to a value of type T.
+func unmarshalJSON[T any](r json.RawMessage) (interface{}, error) {
+	var v T
+	err := json.Unmarshal(r, &v)
+	return v, err
+}
+
+// unmarshalJSONString returns the JSON unmarshalled to a value of type T if
+// it's a number, or the string parsed with p if it's a string.
+func unmarshalJSONString[T any](
+	r json.RawMessage,
+	p func(string) (T, error)) (interface{}, error) {
+	if len(r) == 0 || r[0] != '"' {
+		return unmarshalJSON[T](r)
+	}
+	var s string
+	err := json.Unmarshal(r, &s)
+	if err != nil {
+		return nil, err
+	}
+	return p(s)
+}
@@ -0,0 +1,194 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testJSONSchema = "name:string,label:stringl,tags:strings," +
+	"attrs:stringmap,data:bytearray,chunks:bytearrayarray,id:fixed4," +
+	"ok:bool,b:byte,u16:uint16,u32:uint32,u32s:uint32s,u64:uint64," +
+	"i16:int16,i32:int32,i64:int64,f32:float32,f64:float64,v:uvarint," +
+	"t:time,tc:timecompact,d:date,dm:dateminutes,ds:dateseconds," +
+	"dh:datehours,dh16:datehours16,dms:datemilliseconds"
+
+const testSchemaJSON = `{"name":"Hello","label":"World","tags":["a","b"],` +
+	`"attrs":{"k":"v"},"data":"AQID","chunks":["AQ==","AgM="],` +
+	`"id":"AAECAw==","ok":true,"b":7,"u16":65535,"u32":4294967295,` +
+	`"u32s":[1,2],"u64":"18446744073709551615","i16":-1,"i32":-2,` +
+	`"i64":"-9223372036854775808","f32":1.5,"f64":-2.25,"v":"300",` +
+	`"t":"2022-02-28T12:34:56.789+01:00","tc":"2022-02-28T12:34:56Z",` +
+	`"d":"2024-02-29T00:00:00Z","dm":"2024-02-29T12:34:00Z",` +
+	`"ds":"2024-02-29T12:34:56Z","dh":"2024-02-29T12:00:00Z",` +
+	`"dh16":"2024-02-29T12:00:00Z","dms":"2024-02-29T12:34:56.789Z"}`
+
+// TestJSON verifies JSON converts to binary and back to the same JSON and
+// binary for every field type and both integer formats.
+func TestJSON(t *testing.T) {
+	s, err := ParseSchema(testJSONSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range []Options{{}, {Varint: true}} {
+		b, err := FromJSONWithOptions(s, []byte(testSchemaJSON), o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		j, err := ToJSONWithOptions(s, b, o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(j) != testSchemaJSON {
+			t.Fatalf("'%s' != '%s'", j, testSchemaJSON)
+		}
+		c, err := FromJSONWithOptions(s, j, o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b, c) {
+			t.Fatalf("'%x' != '%x'", b, c)
+		}
+	}
+}
+
+// TestJSONStrings verifies 64-bit integers and floats are read from numbers
+// or strings, and that 64-bit integers and floats that aren't finite are
+// written as strings.
+func TestJSONStrings(t *testing.T) {
+	s, err := ParseSchema("u64:uint64,i64:int64,v:uvarint,f32:float32," +
+		"f64:float64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		in   string
+		want string
+	}{
+		{
+			`{"u64":1,"i64":-1,"v":2,"f32":"NaN","f64":"+Inf"}`,
+			`{"u64":"1","i64":"-1","v":"2","f32":"NaN","f64":"+Inf"}`},
+		{
+			`{"u64":"1","i64":"-1","v":"2","f32":"-Inf","f64":"NaN"}`,
+			`{"u64":"1","i64":"-1","v":"2","f32":"-Inf","f64":"NaN"}`},
+		{
+			`{"u64":"9007199254740993","i64":"-9007199254740993",` +
+				`"v":"3","f32":"1.5","f64":-2.25}`,
+			`{"u64":"9007199254740993","i64":"-9007199254740993",` +
+				`"v":"3","f32":1.5,"f64":-2.25}`},
+	} {
+		b, err := FromJSON(s, []byte(c.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		j, err := ToJSON(s, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(j) != c.want {
+			t.Fatalf("'%s' != '%s'", j, c.want)
+		}
+	}
+	for _, j := range []string{
+		`{"u64":"-1","i64":"1","v":"2","f32":1,"f64":1}`,
+		`{"u64":"1","i64":"1.5","v":"2","f32":1,"f64":1}`,
+		`{"u64":"1","i64":"1","v":"2","f32":"x","f64":1}`,
+	} {
+		if _, err := FromJSON(s, []byte(j)); err == nil {
+			t.Fatalf("expected error for '%s'", j)
+		}
+	}
+}
+
+// TestJSONInvalidUTF8 verifies strings that aren't valid UTF-8 are rejected
+// by ToJSON rather than replaced, which would change the bytes returned by
+// FromJSON.
+func TestJSONInvalidUTF8(t *testing.T) {
+	for _, c := range []struct {
+		schema string
+		write  func(e *Encoder) error
+	}{
+		{"n:string", func(e *Encoder) error {
+			return e.WriteString("a\xffb")
+		}},
+		{"n:stringl", func(e *Encoder) error {
+			return e.WriteStringL("a\xffb")
+		}},
+		{"n:strings", func(e *Encoder) error {
+			return e.WriteStrings([]string{"a", "b\xff"})
+		}},
+		{"n:stringmap", func(e *Encoder) error {
+			return e.WriteStringMap(map[string]string{"\xff": "a"})
+		}},
+	} {
+		t.Run(c.schema, func(t *testing.T) {
+			s, err := ParseSchema(c.schema)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b bytes.Buffer
+			err = c.write(NewEncoder(&b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = ToJSON(s, b.Bytes())
+			var u *ErrInvalidUTF8
+			if !errors.As(err, &u) {
+				t.Fatalf("expected ErrInvalidUTF8 but got '%v'", err)
+			}
+		})
+	}
+}
+
+// TestJSONErrors verifies invalid JSON and data result in errors.
+func TestJSONErrors(t *testing.T) {
+	s, err := ParseSchema("name:string,id:fixed2,d:date")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n, j := range map[string]string{
+		"missing": `{"name":"a","id":"AAE="}`,
+		"extra":   `{"name":"a","id":"AAE=","d":"2022-01-01T00:00:00Z","x":1}`,
+		"fixed":   `{"name":"a","id":"AA==","d":"2022-01-01T00:00:00Z"}`,
+		"type":    `{"name":1,"id":"AAE=","d":"2022-01-01T00:00:00Z"}`,
+		"date":    `{"name":"a","id":"AAE=","d":"2022-01-01"}`,
+		"range":   `{"name":"a","id":"AAE=","d":"2019-01-01T00:00:00Z"}`,
+		"array":   `[]`,
+	} {
+		t.Run(n, func(t *testing.T) {
+			if _, err := FromJSON(s, []byte(j)); err == nil {
+				t.Fatalf("expected error for '%s'", j)
+			}
+		})
+	}
+	b, err := FromJSON(s, []byte(`{"name":"a","id":"AAE=",`+
+		`"d":"2022-01-01T00:00:00Z"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ToJSON(s, append(b, 0))
+	if err == nil || !strings.Contains(err.Error(), "remain") {
+		t.Fatalf("expected remaining bytes error but got '%v'", err)
+	}
+	_, err = ToJSON(s, b[:len(b)-1])
+	if err == nil || !strings.Contains(err.Error(), "'d'") {
+		t.Fatalf("expected field 'd' error but got '%v'", err)
+	}
+}
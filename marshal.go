@@ -0,0 +1,564 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The name of the struct tag used by Marshal and Unmarshal.
+const TagName = "swan"
+
+// Encodings that can be specified in the second part of a swan struct tag.
+// Time fields default to EncodingTime and uint64 fields default to fixed width
+// little endian.
+const (
+	EncodingTime             = "time"             // WriteTime
+	EncodingTimeCompact      = "timecompact"      // WriteTimeCompact
+	EncodingDate             = "date"             // WriteDate
+	EncodingDateMinutes      = "dateminutes"      // WriteDateToUInt32
+	EncodingDateSeconds      = "dateseconds"      // WriteDateSeconds
+	EncodingDateHours        = "datehours"        // WriteDateHours
+	EncodingDateHours16      = "datehours16"      // WriteDateHours16
+	EncodingDateMilliseconds = "datemilliseconds" // WriteDateMilliseconds
+	EncodingUvarint          = "uvarint"          // WriteUvarint
+	EncodingStringL          = "stringl"          // WriteStringL
+)
+
+// InlineMarshaler is implemented by structs that write their tagged fields to
+// an Encoder without a length. The methods generated by swangen implement it
+// so that generating methods for a nested struct doesn't change the layout
+// Marshal produces for the struct containing it.
+type InlineMarshaler interface {
+	MarshalInline(e *Encoder) error
+}
+
+// InlineUnmarshaler is implemented by structs that read tagged fields written
+// by InlineMarshaler.
+type InlineUnmarshaler interface {
+	UnmarshalInline(d *Decoder) error
+}
+
+// Tag is the parsed value of a swan struct tag. The tag `swan:"2,date"` has an
+// Order of 2 and an Encoding of EncodingDate.
+type Tag struct {
+	Order    int    // position of the field in the binary layout
+	Encoding string // optional encoding for the field's type
+}
+
+// ParseTag parses the value of a swan struct tag. The value is the order of
+// the field optionally followed by a comma and the encoding.
+func ParseTag(s string) (Tag, error) {
+	var t Tag
+	o, e, _ := strings.Cut(s, ",")
+	var err error
+	t.Order, err = strconv.Atoi(o)
+	if err != nil {
+		return t, fmt.Errorf("tag '%s' order invalid: %w", s, err)
+	}
+	t.Encoding = e
+	switch e {
+	case "",
+		EncodingTime,
+		EncodingTimeCompact,
+		EncodingDate,
+		EncodingDateMinutes,
+		EncodingDateSeconds,
+		EncodingDateHours,
+		EncodingDateHours16,
+		EncodingDateMilliseconds,
+		EncodingUvarint,
+		EncodingStringL:
+	default:
+		return t, fmt.Errorf("tag '%s' encoding '%s' unknown", s, e)
+	}
+	return t, nil
+}
+
+// Marshal returns the binary encoding of v using the fixed width format. v
+// must be a struct or pointer to a struct. Only fields with a swan tag are
+// written, in the order specified by their tags. The bytes are identical to
+// those produced by calling the Write* function for each field in order.
+//
+// Fields of type string and []byte use WriteString and WriteByteArray, or
+// WriteStringL for string fields with the stringl encoding. Fixed length byte
+// arrays use WriteByteArrayNoLength. Numeric and bool fields use the Write*
+// function of the same type. Other slices are written as a count followed by
+// each element which for []string, [][]byte and []uint32 is the same as
+// WriteStrings, WriteByteArrayArray and WriteUint32s. map[string]string fields
+// use WriteStringMap. time.Time fields use the encoding in the tag. Fields
+// that implement InlineMarshaler, such as structs with methods generated by
+// swangen, are written inline by MarshalInline. Other fields that implement
+// encoding.BinaryMarshaler use WriteMarshaller and other struct fields are
+// written inline.
+func Marshal(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	err := NewEncoder(&b).Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Unmarshal decodes data written by Marshal into v which must be a pointer to
+// a struct. Returns an error if data contains bytes that are not read.
+func Unmarshal(data []byte, v interface{}) error {
+	b := bytes.NewBuffer(data)
+	err := NewDecoder(b).Decode(v)
+	if err != nil {
+		return err
+	}
+	if b.Len() > 0 {
+		return fmt.Errorf("'%d' bytes remain after unmarshal", b.Len())
+	}
+	return nil
+}
+
+// Encode writes the tagged fields of v which must be a struct or a pointer to
+// a struct. See Marshal for details of the layout.
+func (e *Encoder) Encode(v interface{}) error {
+	r := reflect.Indirect(reflect.ValueOf(v))
+	if r.Kind() != reflect.Struct {
+		return fmt.Errorf("type '%T' is not a struct", v)
+	}
+
+	// Copy the struct if needed so that fields are addressable and methods
+	// with pointer receivers are found.
+	if !r.CanAddr() {
+		p := reflect.New(r.Type())
+		p.Elem().Set(r)
+		r = p.Elem()
+	}
+	return e.encodeStruct(r)
+}
+
+// Decode reads the tagged fields of the struct pointed to by v.
+func (d *Decoder) Decode(v interface{}) error {
+	r := reflect.ValueOf(v)
+	if r.Kind() != reflect.Pointer || r.IsNil() ||
+		r.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("type '%T' is not a pointer to a struct", v)
+	}
+	return d.decodeStruct(r.Elem())
+}
+
+// field of a struct that is marshalled.
+type field struct {
+	order    int    // order from the tag
+	index    int    // index of the field in the struct
+	name     string // name of the field used in errors
+	encoding string // encoding from the tag
+}
+
+// Cache of the fields for each struct type.
+var fieldsCache sync.Map
+
+var (
+	timeType              = reflect.TypeOf(time.Time{})
+	stringMapType         = reflect.TypeOf(map[string]string{})
+	marshalerType         = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	unmarshalerType       = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+	inlineMarshalerType   = reflect.TypeOf((*InlineMarshaler)(nil)).Elem()
+	inlineUnmarshalerType = reflect.TypeOf((*InlineUnmarshaler)(nil)).Elem()
+)
+
+// getFields returns the tagged fields of the struct type in order.
+func getFields(t reflect.Type) ([]field, error) {
+	if f, ok := fieldsCache.Load(t); ok {
+		return f.([]field), nil
+	}
+	var f []field
+	o := make(map[int]string)
+	for i := 0; i < t.NumField(); i++ {
+		s := t.Field(i)
+		v, ok := s.Tag.Lookup(TagName)
+		if !ok || v == "-" {
+			continue
+		}
+		if !s.IsExported() {
+			return nil, fmt.Errorf(
+				"field '%s.%s' is not exported",
+				t.Name(),
+				s.Name)
+		}
+		g, err := ParseTag(v)
+		if err != nil {
+			return nil, fmt.Errorf("field '%s.%s': %w", t.Name(), s.Name, err)
+		}
+		if n, ok := o[g.Order]; ok {
+			return nil, fmt.Errorf(
+				"fields '%s' and '%s' of '%s' have order '%d'",
+				n,
+				s.Name,
+				t.Name(),
+				g.Order)
+		}
+		o[g.Order] = s.Name
+		f = append(f, field{
+			order:    g.Order,
+			index:    i,
+			name:     s.Name,
+			encoding: g.Encoding})
+	}
+	sort.Slice(f, func(i, j int) bool { return f[i].order < f[j].order })
+	fieldsCache.Store(t, f)
+	return f, nil
+}
+
+func (e *Encoder) encodeStruct(v reflect.Value) error {
+	f, err := getFields(v.Type())
+	if err != nil {
+		return err
+	}
+	for _, i := range f {
+		err = e.encodeValue(v.Field(i.index), i.encoding)
+		if err != nil {
+			return fmt.Errorf("field '%s': %w", i.name, err)
+		}
+	}
+	return nil
+}
+
+func (e *Encoder) encodeValue(v reflect.Value, enc string) error {
+	t := v.Type()
+	if t == timeType {
+		return e.encodeTime(v.Interface().(time.Time), enc)
+	}
+	if enc == EncodingUvarint {
+		if t.Kind() != reflect.Uint64 {
+			return fmt.Errorf("encoding '%s' requires uint64", enc)
+		}
+		return e.WriteUvarint(v.Uint())
+	}
+	if enc == EncodingStringL {
+		if t.Kind() != reflect.String {
+			return fmt.Errorf("encoding '%s' requires string", enc)
+		}
+		return e.WriteStringL(v.String())
+	}
+	if enc != "" && !encodingApplies(t) {
+		return fmt.Errorf("encoding '%s' not supported for '%s'", enc, t)
+	}
+	if t.Kind() != reflect.Pointer &&
+		v.CanAddr() &&
+		reflect.PointerTo(t).Implements(inlineMarshalerType) {
+		return v.Addr().Interface().(InlineMarshaler).MarshalInline(e)
+	}
+	if t.Implements(marshalerType) {
+		if t.Kind() == reflect.Pointer && v.IsNil() {
+			return fmt.Errorf("nil '%s' can't be marshalled", t)
+		}
+		return e.WriteMarshaller(v.Interface().(encoding.BinaryMarshaler))
+	}
+	if v.CanAddr() && reflect.PointerTo(t).Implements(marshalerType) {
+		return e.WriteMarshaller(
+			v.Addr().Interface().(encoding.BinaryMarshaler))
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return e.WriteString(v.String())
+	case reflect.Bool:
+		return e.WriteBool(v.Bool())
+	case reflect.Uint8:
+		return e.WriteByte(uint8(v.Uint()))
+	case reflect.Uint16:
+		return e.WriteUint16(uint16(v.Uint()))
+	case reflect.Uint32:
+		return e.WriteUint32(uint32(v.Uint()))
+	case reflect.Uint64:
+		return e.WriteUint64(v.Uint())
+	case reflect.Int16:
+		return e.WriteInt16(int16(v.Int()))
+	case reflect.Int32:
+		return e.WriteInt32(int32(v.Int()))
+	case reflect.Int64:
+		return e.WriteInt64(v.Int())
+	case reflect.Float32:
+		return e.WriteFloat32(float32(v.Float()))
+	case reflect.Float64:
+		return e.WriteFloat64(v.Float())
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return e.WriteByteArrayNoLength(b)
+		}
+	case reflect.Slice:
+		return e.encodeSlice(v, enc)
+	case reflect.Map:
+		if t.ConvertibleTo(stringMapType) {
+			return e.WriteStringMap(
+				v.Convert(stringMapType).Interface().(map[string]string))
+		}
+	case reflect.Struct:
+		return e.encodeStruct(v)
+	}
+	return fmt.Errorf("type '%s' not supported", t)
+}
+
+// encodingApplies returns true if an encoding other than uvarint or stringl
+// can apply to a field of type t. Only slices, other than byte slices, pass
+// the encoding on to their elements.
+func encodingApplies(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8
+}
+
+func (e *Encoder) encodeSlice(v reflect.Value, enc string) error {
+	if v.Type().Elem().Kind() == reflect.Uint8 {
+		return e.WriteByteArray(v.Bytes())
+	}
+
+	// Other slices, including []string and [][]byte, are written as the count
+	// followed by each element which is the same as WriteStrings and
+	// WriteByteArrayArray.
+	err := e.writeCount(v.Type().String(), v.Len())
+	if err != nil {
+		return err
+	}
+	for i := 0; i < v.Len(); i++ {
+		err = e.encodeValue(v.Index(i), enc)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Encoder) encodeTime(t time.Time, enc string) error {
+	switch enc {
+	case "", EncodingTime:
+		return e.WriteTime(t)
+	case EncodingTimeCompact:
+		return e.WriteTimeCompact(t)
+	case EncodingDate:
+		return e.WriteDate(t)
+	case EncodingDateMinutes:
+		return e.WriteDateToUInt32(t)
+	case EncodingDateSeconds:
+		return e.WriteDateSeconds(t)
+	case EncodingDateHours:
+		return e.WriteDateHours(t)
+	case EncodingDateHours16:
+		return e.WriteDateHours16(t)
+	case EncodingDateMilliseconds:
+		return e.WriteDateMilliseconds(t)
+	}
+	return fmt.Errorf("encoding '%s' not supported for time", enc)
+}
+
+func (d *Decoder) decodeStruct(v reflect.Value) error {
+	f, err := getFields(v.Type())
+	if err != nil {
+		return err
+	}
+	err = d.enter()
+	if err != nil {
+		return err
+	}
+	defer d.leave()
+	for _, i := range f {
+		err = d.decodeValue(v.Field(i.index), i.encoding)
+		if err != nil {
+			return fmt.Errorf("field '%s': %w", i.name, err)
+		}
+	}
+	return nil
+}
+
+func (d *Decoder) decodeValue(v reflect.Value, enc string) error {
+	t := v.Type()
+	if t == timeType {
+		r, err := d.decodeTime(enc)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(r))
+		return nil
+	}
+	if enc == EncodingUvarint {
+		if t.Kind() != reflect.Uint64 {
+			return fmt.Errorf("encoding '%s' requires uint64", enc)
+		}
+		i, err := d.ReadUvarint()
+		v.SetUint(i)
+		return err
+	}
+	if enc == EncodingStringL {
+		if t.Kind() != reflect.String {
+			return fmt.Errorf("encoding '%s' requires string", enc)
+		}
+		s, err := d.ReadStringL()
+		v.SetString(s)
+		return err
+	}
+	if enc != "" && !encodingApplies(t) {
+		return fmt.Errorf("encoding '%s' not supported for '%s'", enc, t)
+	}
+	if t.Kind() != reflect.Pointer &&
+		reflect.PointerTo(t).Implements(inlineUnmarshalerType) {
+		err := d.enter()
+		if err != nil {
+			return err
+		}
+		defer d.leave()
+		return v.Addr().Interface().(InlineUnmarshaler).UnmarshalInline(d)
+	}
+	if t.Kind() == reflect.Pointer && t.Implements(unmarshalerType) {
+		n := reflect.New(t.Elem())
+		err := d.ReadMarshaller(n.Interface().(encoding.BinaryUnmarshaler))
+		if err != nil {
+			return err
+		}
+		v.Set(n)
+		return nil
+	}
+	if reflect.PointerTo(t).Implements(unmarshalerType) {
+		return d.ReadMarshaller(
+			v.Addr().Interface().(encoding.BinaryUnmarshaler))
+	}
+	switch t.Kind() {
+	case reflect.String:
+		s, err := d.ReadString()
+		v.SetString(s)
+		return err
+	case reflect.Bool:
+		b, err := d.ReadBool()
+		v.SetBool(b)
+		return err
+	case reflect.Uint8:
+		i, err := d.ReadByte()
+		v.SetUint(uint64(i))
+		return err
+	case reflect.Uint16:
+		i, err := d.ReadUint16()
+		v.SetUint(uint64(i))
+		return err
+	case reflect.Uint32:
+		i, err := d.ReadUint32()
+		v.SetUint(uint64(i))
+		return err
+	case reflect.Uint64:
+		i, err := d.ReadUint64()
+		v.SetUint(i)
+		return err
+	case reflect.Int16:
+		i, err := d.ReadInt16()
+		v.SetInt(int64(i))
+		return err
+	case reflect.Int32:
+		i, err := d.ReadInt32()
+		v.SetInt(int64(i))
+		return err
+	case reflect.Int64:
+		i, err := d.ReadInt64()
+		v.SetInt(i)
+		return err
+	case reflect.Float32:
+		f, err := d.ReadFloat32()
+		v.SetFloat(float64(f))
+		return err
+	case reflect.Float64:
+		f, err := d.ReadFloat64()
+		v.SetFloat(f)
+		return err
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			b, err := d.ReadByteArrayNoLength(v.Len())
+			if err != nil {
+				return err
+			}
+			reflect.Copy(v, reflect.ValueOf(b))
+			return nil
+		}
+	case reflect.Slice:
+		return d.decodeSlice(v, enc)
+	case reflect.Map:
+		if t.ConvertibleTo(stringMapType) {
+			m, err := d.ReadStringMap()
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(m).Convert(t))
+			return nil
+		}
+	case reflect.Struct:
+		return d.decodeStruct(v)
+	}
+	return fmt.Errorf("type '%s' not supported", t)
+}
+
+func (d *Decoder) decodeSlice(v reflect.Value, enc string) error {
+	t := v.Type().Elem()
+	if t.Kind() == reflect.Uint8 {
+		b, err := d.ReadByteArray()
+		if err != nil {
+			return err
+		}
+		v.SetBytes(append([]byte(nil), b...))
+		return nil
+	}
+	c, err := d.readCount()
+	if err != nil {
+		return err
+	}
+	err = d.enter()
+	if err != nil {
+		return err
+	}
+	defer d.leave()
+	s := reflect.MakeSlice(v.Type(), 0, d.capacity(c))
+	for i := 0; i < c; i++ {
+		e := reflect.New(t).Elem()
+		err = d.decodeValue(e, enc)
+		if err != nil {
+			return err
+		}
+		s = reflect.Append(s, e)
+	}
+	v.Set(s)
+	return nil
+}
+
+func (d *Decoder) decodeTime(enc string) (time.Time, error) {
+	switch enc {
+	case "", EncodingTime:
+		return d.ReadTime()
+	case EncodingTimeCompact:
+		return d.ReadTimeCompact()
+	case EncodingDate:
+		return d.ReadDate()
+	case EncodingDateMinutes:
+		return d.ReadDateFromUInt32()
+	case EncodingDateSeconds:
+		return d.ReadDateSeconds()
+	case EncodingDateHours:
+		return d.ReadDateHours()
+	case EncodingDateHours16:
+		return d.ReadDateHours16()
+	case EncodingDateMilliseconds:
+		return d.ReadDateMilliseconds()
+	}
+	return time.Time{}, fmt.Errorf("encoding '%s' not supported for time", enc)
+}
@@ -0,0 +1,325 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testMarshaller implements encoding.BinaryMarshaler with pointer receivers.
+type testMarshaller struct {
+	value string
+}
+
+func (m *testMarshaller) MarshalBinary() ([]byte, error) {
+	return []byte(m.value), nil
+}
+
+func (m *testMarshaller) UnmarshalBinary(data []byte) error {
+	m.value = string(data)
+	return nil
+}
+
+type testInner struct {
+	Name  string `swan:"1"`
+	Count uint16 `swan:"2"`
+}
+
+type testRecord struct {
+	Ignored     string
+	Strings     []string          `swan:"3"`
+	Name        string            `swan:"1"`
+	Data        []byte            `swan:"2"`
+	Arrays      [][]byte          `swan:"4"`
+	Fixed       [4]byte           `swan:"5"`
+	Bool        bool              `swan:"6"`
+	Byte        byte              `swan:"7"`
+	U16         uint16            `swan:"8"`
+	U32         uint32            `swan:"9"`
+	U64         uint64            `swan:"10"`
+	I16         int16             `swan:"11"`
+	I32         int32             `swan:"12"`
+	I64         int64             `swan:"13"`
+	F32         float32           `swan:"14"`
+	F64         float64           `swan:"15"`
+	Varint      uint64            `swan:"16,uvarint"`
+	Time        time.Time         `swan:"17"`
+	Compact     time.Time         `swan:"18,timecompact"`
+	Date        time.Time         `swan:"19,date"`
+	Minutes     time.Time         `swan:"20,dateminutes"`
+	Seconds     time.Time         `swan:"21,dateseconds"`
+	Hours       time.Time         `swan:"22,datehours"`
+	Hours16     time.Time         `swan:"23,datehours16"`
+	Millis      time.Time         `swan:"24,datemilliseconds"`
+	Inner       testInner         `swan:"25"`
+	Inners      []testInner       `swan:"26"`
+	Marshaller  testMarshaller    `swan:"27"`
+	MarshallerP *testMarshaller   `swan:"28"`
+	Dates       []time.Time       `swan:"29,date"`
+	StringL     string            `swan:"30,stringl"`
+	Labels      map[string]string `swan:"31"`
+	Uint32s     []uint32          `swan:"32"`
+	Skip        string            `swan:"-"`
+	Map         map[string]string `json:"map"`
+}
+
+func newTestRecord() *testRecord {
+	d := time.Date(2022, time.March, 4, 0, 0, 0, 0, time.UTC)
+	m := time.Date(2022, time.March, 4, 5, 6, 7, 8000000, time.UTC)
+	return &testRecord{
+		Name:        "Hello",
+		Data:        []byte{1, 2, 3},
+		Strings:     []string{"A", "B"},
+		Arrays:      [][]byte{{1}, {2, 3}},
+		Fixed:       [4]byte{9, 8, 7, 6},
+		Bool:        true,
+		Byte:        0x12,
+		U16:         0x1234,
+		U32:         0x12345678,
+		U64:         0x123456789ABCDEF0,
+		I16:         -16,
+		I32:         -32,
+		I64:         -64,
+		F32:         1.5,
+		F64:         -2.25,
+		Varint:      300,
+		Time:        m,
+		Compact:     m,
+		Date:        d,
+		Minutes:     m.Truncate(time.Minute),
+		Seconds:     m.Truncate(time.Second),
+		Hours:       m.Truncate(time.Hour),
+		Hours16:     m.Truncate(time.Hour),
+		Millis:      m,
+		Inner:       testInner{Name: "Inner", Count: 2},
+		Inners:      []testInner{{"X", 1}, {"Y", 2}},
+		Marshaller:  testMarshaller{value: "M"},
+		MarshallerP: &testMarshaller{value: "P"},
+		Dates:       []time.Time{d, d.AddDate(0, 0, 1)},
+		StringL:     "A\x00B",
+		Labels:      map[string]string{"b": "2", "a": "1"},
+		Uint32s:     []uint32{1, 0xFFFFFFFF}}
+}
+
+// writeTestRecord writes the record with the Write* functions in the order of
+// the tags.
+func writeTestRecord(t *testing.T, b *bytes.Buffer, r *testRecord) {
+	e := func(err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	e(WriteString(b, r.Name))
+	e(WriteByteArray(b, r.Data))
+	e(WriteStrings(b, r.Strings))
+	e(WriteByteArrayArray(b, r.Arrays))
+	e(WriteByteArrayNoLength(b, r.Fixed[:]))
+	e(WriteBool(b, r.Bool))
+	e(WriteByte(b, r.Byte))
+	e(WriteUint16(b, r.U16))
+	e(WriteUint32(b, r.U32))
+	e(WriteUint64(b, r.U64))
+	e(WriteInt16(b, r.I16))
+	e(WriteInt32(b, r.I32))
+	e(WriteInt64(b, r.I64))
+	e(WriteFloat32(b, r.F32))
+	e(WriteFloat64(b, r.F64))
+	e(WriteUvarint(b, r.Varint))
+	e(WriteTime(b, r.Time))
+	e(WriteTimeCompact(b, r.Compact))
+	e(WriteDate(b, r.Date))
+	e(WriteDateToUInt32(b, r.Minutes))
+	e(WriteDateSeconds(b, r.Seconds))
+	e(WriteDateHours(b, r.Hours))
+	e(WriteDateHours16(b, r.Hours16))
+	e(WriteDateMilliseconds(b, r.Millis))
+	e(WriteString(b, r.Inner.Name))
+	e(WriteUint16(b, r.Inner.Count))
+	e(WriteUint16(b, uint16(len(r.Inners))))
+	for _, i := range r.Inners {
+		e(WriteString(b, i.Name))
+		e(WriteUint16(b, i.Count))
+	}
+	e(WriteMarshaller(b, &r.Marshaller))
+	e(WriteMarshaller(b, r.MarshallerP))
+	e(WriteUint16(b, uint16(len(r.Dates))))
+	for _, d := range r.Dates {
+		e(WriteDate(b, d))
+	}
+	e(WriteStringL(b, r.StringL))
+	e(WriteStringMap(b, r.Labels))
+	e(WriteUint32s(b, r.Uint32s))
+}
+
+// TestMarshal verifies Marshal produces the same bytes as the Write* functions
+// and that Unmarshal restores the tagged fields.
+func TestMarshal(t *testing.T) {
+	r := newTestRecord()
+	r.Ignored = "ignored"
+	r.Skip = "skip"
+	var b bytes.Buffer
+	writeTestRecord(t, &b, r)
+	for _, v := range []interface{}{r, *r} {
+		m, err := Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(m, b.Bytes()) {
+			t.Fatal("marshal and Write* functions produced different bytes")
+		}
+	}
+	var u testRecord
+	if err := Unmarshal(b.Bytes(), &u); err != nil {
+		t.Fatal(err)
+	}
+	r.Ignored = ""
+	r.Skip = ""
+	if !reflect.DeepEqual(&u, r) {
+		t.Fatalf("unmarshalled '%v' != '%v'", u, *r)
+	}
+}
+
+// testInline implements InlineMarshaler and InlineUnmarshaler with the same
+// layout as testInner.
+type testInline struct {
+	name  string
+	count uint16
+}
+
+func (i *testInline) MarshalInline(e *Encoder) error {
+	err := e.WriteString(i.name)
+	if err != nil {
+		return err
+	}
+	return e.WriteUint16(i.count)
+}
+
+func (i *testInline) UnmarshalInline(d *Decoder) error {
+	var err error
+	i.name, err = d.ReadString()
+	if err != nil {
+		return err
+	}
+	i.count, err = d.ReadUint16()
+	return err
+}
+
+// TestMarshalInline verifies fields that implement InlineMarshaler are written
+// inline with the same layout as a struct without methods.
+func TestMarshalInline(t *testing.T) {
+	type inline struct {
+		I []testInline `swan:"1"`
+	}
+	type plain struct {
+		I []testInner `swan:"1"`
+	}
+	i := inline{I: []testInline{{"A", 1}, {"B", 2}}}
+	a, err := Marshal(i)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Marshal(plain{I: []testInner{{"A", 1}, {"B", 2}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Fatalf("'%x' != '%x'", a, b)
+	}
+	var u inline
+	if err := Unmarshal(a, &u); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(u, i) {
+		t.Fatalf("unmarshalled '%v' != '%v'", u, i)
+	}
+}
+
+// TestMarshalErrors verifies invalid types and data result in errors.
+func TestMarshalErrors(t *testing.T) {
+	t.Run("not struct", func(t *testing.T) {
+		if _, err := Marshal("A"); err == nil {
+			t.Fatal("expected error")
+		}
+		var s string
+		if err := Unmarshal([]byte{0}, &s); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("duplicate order", func(t *testing.T) {
+		v := struct {
+			A string `swan:"1"`
+			B string `swan:"1"`
+		}{}
+		if _, err := Marshal(v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("unknown encoding", func(t *testing.T) {
+		v := struct {
+			A time.Time `swan:"1,fortnights"`
+		}{}
+		if _, err := Marshal(v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("encoding not supported", func(t *testing.T) {
+		for _, v := range []interface{}{
+			&struct {
+				N uint16 `swan:"1,date"`
+			}{},
+			&struct {
+				S string `swan:"1,date"`
+			}{},
+			&struct {
+				B []byte `swan:"1,timecompact"`
+			}{},
+			&struct {
+				M *testMarshaller `swan:"1,date"`
+			}{M: &testMarshaller{}},
+		} {
+			if _, err := Marshal(v); err == nil {
+				t.Fatalf("expected error for '%T'", v)
+			}
+			if err := Unmarshal([]byte{0, 0, 0, 0, 0}, v); err == nil {
+				t.Fatalf("expected error for '%T'", v)
+			}
+		}
+	})
+	t.Run("unsupported type", func(t *testing.T) {
+		v := struct {
+			A int `swan:"1"`
+		}{}
+		if _, err := Marshal(v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("trailing bytes", func(t *testing.T) {
+		var v testInner
+		if err := Unmarshal([]byte{'A', 0, 1, 0, 9}, &v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("truncated", func(t *testing.T) {
+		var v testInner
+		if err := Unmarshal([]byte{'A', 0, 1}, &v); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
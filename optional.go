@@ -0,0 +1,55 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import "fmt"
+
+// WriteOptional writes a presence flag byte of 1 followed by the value written
+// by f if v is not nil, or a presence flag of 0 if v is nil. For example
+// WriteOptional(e, name, (*Encoder).WriteString).
+func WriteOptional[T any](e *Encoder, v *T, f func(*Encoder, T) error) error {
+	if v == nil {
+		return e.WriteByte(0)
+	}
+	err := e.WriteByte(1)
+	if err != nil {
+		return err
+	}
+	return f(e, *v)
+}
+
+// ReadOptional reads a value written by WriteOptional using f to read the
+// value if present. Returns nil if the value is absent, and an error if the
+// presence flag is not 0 or 1. For example
+// ReadOptional(d, (*Decoder).ReadString).
+func ReadOptional[T any](d *Decoder, f func(*Decoder) (T, error)) (*T, error) {
+	p, err := d.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch p {
+	case 0:
+		return nil, nil
+	case 1:
+		v, err := f(d)
+		if err != nil {
+			return nil, err
+		}
+		return &v, nil
+	}
+	return nil, fmt.Errorf("presence flag '%d' invalid", p)
+}
@@ -0,0 +1,78 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// TestOptional verifies present and absent values round trip and that invalid
+// presence flags result in errors.
+func TestOptional(t *testing.T) {
+	s := "Hello"
+	d := time.Date(2022, time.March, 4, 0, 0, 0, 0, time.UTC)
+	var b bytes.Buffer
+	e := NewEncoder(&b)
+	if err := WriteOptional(e, &s, (*Encoder).WriteString); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteOptional(e, nil, (*Encoder).WriteString); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteOptional(e, &d, (*Encoder).WriteDate); err != nil {
+		t.Fatal(err)
+	}
+	if b.Len() != 1+len(s)+1+1+1+2 {
+		t.Fatalf("length '%d' incorrect", b.Len())
+	}
+	r := NewDecoder(&b)
+	a, err := ReadOptional(r, (*Decoder).ReadString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == nil || *a != s {
+		t.Fatalf("'%v' != '%s'", a, s)
+	}
+	a, err = ReadOptional(r, (*Decoder).ReadString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a != nil {
+		t.Fatalf("'%s' not absent", *a)
+	}
+	c, err := ReadOptional(r, (*Decoder).ReadDate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c == nil || !c.Equal(d) {
+		t.Fatalf("'%v' != '%s'", c, d)
+	}
+	t.Run("invalid flag", func(t *testing.T) {
+		d := NewDecoder(bytes.NewBuffer([]byte{2, 'A', 0}))
+		if _, err := ReadOptional(d, (*Decoder).ReadString); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("truncated", func(t *testing.T) {
+		d := NewDecoder(bytes.NewBuffer([]byte{1, 'A'}))
+		if _, err := ReadOptional(d, (*Decoder).ReadString); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
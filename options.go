@@ -0,0 +1,104 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+// Options control the format used by an Encoder or Decoder. The zero value
+// uses the fixed width format of the Write* and Read* functions in io.go. The
+// same options must be used to decode data as were used to encode it.
+type Options struct {
+
+	// Varint when true stores byte array and string lengths, array counts and
+	// dates as unsigned varints (LEB128) rather than fixed width integers.
+	// Strings are prefixed with their length instead of being null
+	// terminated.
+	Varint bool
+
+	// Epoch from which dates are encoded. The zero value is the default
+	// epoch of 2020-01-01 UTC.
+	Epoch Epoch
+
+	// Limits on the size of values written and read.
+	Limits Limits
+
+	// ValidateUTF8 when true returns ErrInvalidUTF8 when a string that is not
+	// valid UTF-8 is written or read. Set when reading data that originates
+	// from untrusted sources such as browsers.
+	ValidateUTF8 bool
+
+	// Canonical when true ensures identical values always produce identical
+	// bytes so that signatures over the bytes are reproducible. Encoders
+	// convert times to UTC. Decoders return ErrNotCanonical for data that
+	// could not have been written by a canonical encoder such as times that
+	// are not UTC, varints that use more bytes than needed, booleans other
+	// than 0 or 1, and string maps with keys out of order.
+	Canonical bool
+}
+
+// Limits on the size of values. A zero value for any field means only the
+// maximum the format can represent applies. Encoders return ErrTooLong and
+// decoders return ErrLimitExceeded when a limit is exceeded. Decoders check
+// limits before allocating memory so that hostile data can't cause large
+// allocations.
+type Limits struct {
+	MaxLength       int // maximum number of bytes in a byte array or string
+	MaxStringLength int // maximum number of bytes in a string
+	MaxCount        int // maximum number of elements in an array
+	MaxBytes        int // maximum bytes a decoder will read in total
+
+	// MaxDepth is the maximum nesting a decoder will read. Arrays, string
+	// maps, values read by ReadMarshaller and structs read by Decode each add
+	// a level, including the top-level struct passed to Decode. For example
+	// decoding a struct with a []string field needs a MaxDepth of at least 2.
+	MaxDepth int
+
+	// MaxDecompressed is the maximum number of bytes a compressed byte array
+	// can decompress to. Zero uses IoMaxDecompressedLength rather than the
+	// format maximum so that compressed data can't be used to exhaust memory.
+	MaxDecompressed int
+}
+
+// stringLength returns the limit that applies to the length of strings and
+// its name.
+func (l Limits) stringLength() (string, int) {
+	if l.MaxStringLength > 0 &&
+		(l.MaxLength == 0 || l.MaxStringLength < l.MaxLength) {
+		return "MaxStringLength", l.MaxStringLength
+	}
+	return "MaxLength", l.MaxLength
+}
+
+// decompressed returns the limit that applies to the length of decompressed
+// byte arrays.
+func (l Limits) decompressed() int {
+	m := l.MaxDecompressed
+	if m <= 0 {
+		m = IoMaxDecompressedLength
+	}
+	if l.MaxLength > 0 && l.MaxLength < m {
+		m = l.MaxLength
+	}
+	return m
+}
+
+// limit returns the smaller of the configured limit l and the format maximum
+// m.
+func limit(l int, m int64) int64 {
+	if l > 0 && int64(l) < m {
+		return int64(l)
+	}
+	return m
+}
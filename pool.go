@@ -0,0 +1,51 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"sync"
+)
+
+// The largest capacity of a buffer that PutBuffer will return to the pool.
+// Larger buffers are left for the garbage collector so that an occasional
+// large value does not keep a large amount of memory in the pool.
+const maxPooledBufferCapacity = 64 << 10
+
+// bufferPool contains empty buffers ready for reuse.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// GetBuffer returns an empty buffer from the pool. The buffer should be
+// returned with PutBuffer once the bytes it contains are no longer needed.
+func GetBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// PutBuffer resets the buffer and returns it to the pool. The buffer, and any
+// slices returned from its Bytes method or read from it without copying, must
+// not be used after calling PutBuffer.
+func PutBuffer(b *bytes.Buffer) {
+	if b == nil || b.Cap() > maxPooledBufferCapacity {
+		return
+	}
+	b.Reset()
+	bufferPool.Put(b)
+}
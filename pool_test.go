@@ -0,0 +1,50 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestBufferPool verifies buffers from the pool are empty and that large
+// buffers are not returned to the pool.
+func TestBufferPool(t *testing.T) {
+	b := GetBuffer()
+	if b.Len() != 0 {
+		t.Fatal("buffer not empty")
+	}
+	if err := WriteString(b, "A"); err != nil {
+		t.Fatal(err)
+	}
+	PutBuffer(b)
+	for i := 0; i < 10; i++ {
+		b = GetBuffer()
+		if b.Len() != 0 {
+			t.Fatal("buffer not empty")
+		}
+		PutBuffer(b)
+	}
+	PutBuffer(nil)
+	l := bytes.NewBuffer(make([]byte, 0, maxPooledBufferCapacity+1))
+	PutBuffer(l)
+	for i := 0; i < 10; i++ {
+		if GetBuffer() == l {
+			t.Fatal("large buffer returned to pool")
+		}
+	}
+}
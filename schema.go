@@ -0,0 +1,291 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldType is the type of a field in a Schema. Each type is read and written
+// with the Read* and Write* functions of the same name, for example
+// FieldStringMap with WriteStringMap and ReadStringMap. FieldFixed uses
+// WriteByteArrayNoLength and FieldDateMinutes uses WriteDateToUInt32. The
+// time types have the same names as the encodings of swan struct tags.
+type FieldType string
+
+const (
+	FieldString           FieldType = "string"
+	FieldStringL          FieldType = "stringl"
+	FieldStrings          FieldType = "strings"
+	FieldStringMap        FieldType = "stringmap"
+	FieldByteArray        FieldType = "bytearray"
+	FieldByteArrayArray   FieldType = "bytearrayarray"
+	FieldFixed            FieldType = "fixed"
+	FieldBool             FieldType = "bool"
+	FieldByte             FieldType = "byte"
+	FieldUint16           FieldType = "uint16"
+	FieldUint32           FieldType = "uint32"
+	FieldUint32s          FieldType = "uint32s"
+	FieldUint64           FieldType = "uint64"
+	FieldInt16            FieldType = "int16"
+	FieldInt32            FieldType = "int32"
+	FieldInt64            FieldType = "int64"
+	FieldFloat32          FieldType = "float32"
+	FieldFloat64          FieldType = "float64"
+	FieldUvarint          FieldType = "uvarint"
+	FieldTime             FieldType = "time"
+	FieldTimeCompact      FieldType = "timecompact"
+	FieldDate             FieldType = "date"
+	FieldDateMinutes      FieldType = "dateminutes"
+	FieldDateSeconds      FieldType = "dateseconds"
+	FieldDateHours        FieldType = "datehours"
+	FieldDateHours16      FieldType = "datehours16"
+	FieldDateMilliseconds FieldType = "datemilliseconds"
+)
+
+// Field is a named value in a Schema.
+type Field struct {
+	Name   string    // name of the field used in output
+	Type   FieldType // type of the field
+	Length int       // number of bytes if the type is FieldFixed
+}
+
+func (f Field) String() string {
+	if f.Type == FieldFixed {
+		return fmt.Sprintf("%s:%s%d", f.Name, f.Type, f.Length)
+	}
+	return fmt.Sprintf("%s:%s", f.Name, f.Type)
+}
+
+// Schema describes the sequence of fields in a payload written with the
+// Write* functions so that the payload can be inspected without the Go type
+// that wrote it.
+type Schema []Field
+
+// ParseSchema parses a comma separated list of fields in the form name:type,
+// for example "name:string,expires:date,id:fixed16". Fixed length byte arrays
+// are the type fixed followed by the number of bytes. Field names must be
+// unique.
+func ParseSchema(s string) (Schema, error) {
+	var r Schema
+	names := make(map[string]bool)
+	for _, i := range strings.Split(s, ",") {
+		n, t, ok := strings.Cut(strings.TrimSpace(i), ":")
+		if !ok || n == "" {
+			return nil, fmt.Errorf("field '%s' not name:type", i)
+		}
+		if names[n] {
+			return nil, fmt.Errorf("field '%s' duplicated", n)
+		}
+		names[n] = true
+		f := Field{Name: n, Type: FieldType(t)}
+		if strings.HasPrefix(t, string(FieldFixed)) && t != string(FieldFixed) {
+			l := strings.TrimPrefix(t, string(FieldFixed))
+			var err error
+			f.Type = FieldFixed
+			f.Length, err = strconv.Atoi(l)
+			if err != nil || f.Length < 0 {
+				return nil, fmt.Errorf("field '%s' length '%s' invalid", n, l)
+			}
+		}
+		err := f.validate()
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, f)
+	}
+	return r, nil
+}
+
+// String returns the schema in the form parsed by ParseSchema.
+func (s Schema) String() string {
+	f := make([]string, len(s))
+	for i, v := range s {
+		f[i] = v.String()
+	}
+	return strings.Join(f, ",")
+}
+
+// validate returns an error if the field's type is unknown.
+func (f Field) validate() error {
+	switch f.Type {
+	case FieldString,
+		FieldStringL,
+		FieldStrings,
+		FieldStringMap,
+		FieldByteArray,
+		FieldByteArrayArray,
+		FieldFixed,
+		FieldBool,
+		FieldByte,
+		FieldUint16,
+		FieldUint32,
+		FieldUint32s,
+		FieldUint64,
+		FieldInt16,
+		FieldInt32,
+		FieldInt64,
+		FieldFloat32,
+		FieldFloat64,
+		FieldUvarint,
+		FieldTime,
+		FieldTimeCompact,
+		FieldDate,
+		FieldDateMinutes,
+		FieldDateSeconds,
+		FieldDateHours,
+		FieldDateHours16,
+		FieldDateMilliseconds:
+		return nil
+	}
+	return fmt.Errorf("field '%s' type '%s' unknown", f.Name, f.Type)
+}
+
+// read returns the value of the field read with the Read* function for its
+// type.
+func (f Field) read(d *Decoder) (interface{}, error) {
+	switch f.Type {
+	case FieldString:
+		return d.ReadString()
+	case FieldStringL:
+		return d.ReadStringL()
+	case FieldStrings:
+		return d.ReadStrings()
+	case FieldStringMap:
+		return d.ReadStringMap()
+	case FieldByteArray:
+		return d.ReadByteArray()
+	case FieldByteArrayArray:
+		return d.ReadByteArrayArray()
+	case FieldFixed:
+		return d.ReadByteArrayNoLength(f.Length)
+	case FieldBool:
+		return d.ReadBool()
+	case FieldByte:
+		return d.ReadByte()
+	case FieldUint16:
+		return d.ReadUint16()
+	case FieldUint32:
+		return d.ReadUint32()
+	case FieldUint32s:
+		return d.ReadUint32s()
+	case FieldUint64:
+		return d.ReadUint64()
+	case FieldInt16:
+		return d.ReadInt16()
+	case FieldInt32:
+		return d.ReadInt32()
+	case FieldInt64:
+		return d.ReadInt64()
+	case FieldFloat32:
+		return d.ReadFloat32()
+	case FieldFloat64:
+		return d.ReadFloat64()
+	case FieldUvarint:
+		return d.ReadUvarint()
+	case FieldTime:
+		return d.ReadTime()
+	case FieldTimeCompact:
+		return d.ReadTimeCompact()
+	case FieldDate:
+		return d.ReadDate()
+	case FieldDateMinutes:
+		return d.ReadDateFromUInt32()
+	case FieldDateSeconds:
+		return d.ReadDateSeconds()
+	case FieldDateHours:
+		return d.ReadDateHours()
+	case FieldDateHours16:
+		return d.ReadDateHours16()
+	case FieldDateMilliseconds:
+		return d.ReadDateMilliseconds()
+	}
+	return nil, f.validate()
+}
+
+// write writes the value, which must be of the type returned by read, with
+// the Write* function for the field's type.
+func (f Field) write(e *Encoder, v interface{}) error {
+	switch f.Type {
+	case FieldString:
+		return e.WriteString(v.(string))
+	case FieldStringL:
+		return e.WriteStringL(v.(string))
+	case FieldStrings:
+		return e.WriteStrings(v.([]string))
+	case FieldStringMap:
+		return e.WriteStringMap(v.(map[string]string))
+	case FieldByteArray:
+		return e.WriteByteArray(v.([]byte))
+	case FieldByteArrayArray:
+		return e.WriteByteArrayArray(v.([][]byte))
+	case FieldFixed:
+		b := v.([]byte)
+		if len(b) != f.Length {
+			return fmt.Errorf(
+				"field '%s' length '%d' not '%d'",
+				f.Name,
+				len(b),
+				f.Length)
+		}
+		return e.WriteByteArrayNoLength(b)
+	case FieldBool:
+		return e.WriteBool(v.(bool))
+	case FieldByte:
+		return e.WriteByte(v.(byte))
+	case FieldUint16:
+		return e.WriteUint16(v.(uint16))
+	case FieldUint32:
+		return e.WriteUint32(v.(uint32))
+	case FieldUint32s:
+		return e.WriteUint32s(v.([]uint32))
+	case FieldUint64:
+		return e.WriteUint64(v.(uint64))
+	case FieldInt16:
+		return e.WriteInt16(v.(int16))
+	case FieldInt32:
+		return e.WriteInt32(v.(int32))
+	case FieldInt64:
+		return e.WriteInt64(v.(int64))
+	case FieldFloat32:
+		return e.WriteFloat32(v.(float32))
+	case FieldFloat64:
+		return e.WriteFloat64(v.(float64))
+	case FieldUvarint:
+		return e.WriteUvarint(v.(uint64))
+	case FieldTime:
+		return e.WriteTime(v.(time.Time))
+	case FieldTimeCompact:
+		return e.WriteTimeCompact(v.(time.Time))
+	case FieldDate:
+		return e.WriteDate(v.(time.Time))
+	case FieldDateMinutes:
+		return e.WriteDateToUInt32(v.(time.Time))
+	case FieldDateSeconds:
+		return e.WriteDateSeconds(v.(time.Time))
+	case FieldDateHours:
+		return e.WriteDateHours(v.(time.Time))
+	case FieldDateHours16:
+		return e.WriteDateHours16(v.(time.Time))
+	case FieldDateMilliseconds:
+		return e.WriteDateMilliseconds(v.(time.Time))
+	}
+	return f.validate()
+}
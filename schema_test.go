@@ -0,0 +1,52 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import "testing"
+
+// TestParseSchema verifies schemas parse and format consistently and that
+// invalid schemas result in errors.
+func TestParseSchema(t *testing.T) {
+	v := "name:string,id:fixed16,expires:date,created:dateminutes,tags:stringmap"
+	s, err := ParseSchema(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s) != 5 {
+		t.Fatalf("'%d' fields", len(s))
+	}
+	if s[1].Type != FieldFixed || s[1].Length != 16 {
+		t.Fatalf("field '%v' incorrect", s[1])
+	}
+	if s.String() != v {
+		t.Fatalf("'%s' != '%s'", s, v)
+	}
+	for _, i := range []string{
+		"",
+		"name",
+		":string",
+		"name:unknown",
+		"id:fixedA",
+		"id:fixed-1",
+		"name:string,",
+		"name:string,name:bytearray",
+	} {
+		if _, err := ParseSchema(i); err == nil {
+			t.Fatalf("expected error for '%s'", i)
+		}
+	}
+}
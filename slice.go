@@ -0,0 +1,111 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import "encoding"
+
+// WriteSlice writes the number of elements followed by each element written
+// by f. For example WriteSlice(e, v, (*Encoder).WriteDate). Returns
+// ErrTooLong if the number of elements can't be represented or exceeds the
+// MaxCount limit.
+func WriteSlice[T any](e *Encoder, v []T, f func(*Encoder, T) error) error {
+	return writeSlice(e, "slice", v, f)
+}
+
+// ReadSlice reads a slice written by WriteSlice using f to read each element.
+// For example ReadSlice(d, (*Decoder).ReadDate).
+func ReadSlice[T any](d *Decoder, f func(*Decoder) (T, error)) ([]T, error) {
+	return readSlice(d, f)
+}
+
+// WriteMarshallers writes the number of elements followed by each element with
+// WriteMarshaller. The elements, or pointers to them, must implement
+// encoding.BinaryMarshaler.
+func WriteMarshallers[T any, P interface {
+	*T
+	encoding.BinaryMarshaler
+}](e *Encoder, v []T) error {
+	err := e.writeCount("marshallers", len(v))
+	if err != nil {
+		return err
+	}
+	for i := range v {
+		err = e.WriteMarshaller(P(&v[i]))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadMarshallers reads a slice written by WriteMarshallers. Pointers to the
+// elements must implement encoding.BinaryUnmarshaler. For example
+// ReadMarshallers[Record](d).
+func ReadMarshallers[T any, P interface {
+	*T
+	encoding.BinaryUnmarshaler
+}](d *Decoder) ([]T, error) {
+	return readSlice(d, func(d *Decoder) (T, error) {
+		var v T
+		err := d.ReadMarshaller(P(&v))
+		return v, err
+	})
+}
+
+// writeSlice writes the number of elements followed by each element written
+// by f. n is the type of slice used in errors.
+func writeSlice[T any](
+	e *Encoder,
+	n string,
+	v []T,
+	f func(*Encoder, T) error) error {
+	err := e.writeCount(n, len(v))
+	if err != nil {
+		return err
+	}
+	for _, i := range v {
+		err = f(e, i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readSlice reads the number of elements followed by each element read by f.
+// The capacity allocated before the elements are read is limited so that
+// hostile counts can't cause large allocations.
+func readSlice[T any](d *Decoder, f func(*Decoder) (T, error)) ([]T, error) {
+	c, err := d.readCount()
+	if err != nil {
+		return nil, err
+	}
+	err = d.enter()
+	if err != nil {
+		return nil, err
+	}
+	defer d.leave()
+	v := make([]T, 0, d.capacity(c))
+	for i := 0; i < c; i++ {
+		e, err := f(d)
+		if err != nil {
+			return nil, err
+		}
+		v = append(v, e)
+	}
+	return v, nil
+}
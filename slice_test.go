@@ -0,0 +1,122 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestSlice verifies slices round trip with element functions and that the
+// bytes match WriteStrings.
+func TestSlice(t *testing.T) {
+	s := []string{"A", "", "BC"}
+	m := DefaultEpoch().Base()
+	d := []time.Time{m, m.AddDate(0, 0, 1)}
+	var b, w bytes.Buffer
+	e := NewEncoder(&b)
+	if err := WriteSlice(e, s, (*Encoder).WriteString); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteStrings(&w, s); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.Bytes(), w.Bytes()) {
+		t.Fatal("WriteSlice and WriteStrings produced different bytes")
+	}
+	if err := WriteSlice(e, d, (*Encoder).WriteDate); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteSlice(e, nil, (*Encoder).WriteDate); err != nil {
+		t.Fatal(err)
+	}
+	r := NewDecoder(&b)
+	rs, err := ReadSlice(r, (*Decoder).ReadString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rs, s) {
+		t.Fatalf("'%v' != '%v'", rs, s)
+	}
+	rd, err := ReadSlice(r, (*Decoder).ReadDate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rd, d) {
+		t.Fatalf("'%v' != '%v'", rd, d)
+	}
+	rd, err = ReadSlice(r, (*Decoder).ReadDate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rd) != 0 {
+		t.Fatalf("'%v' not empty", rd)
+	}
+	t.Run("limits", func(t *testing.T) {
+		o := Options{Limits: Limits{MaxCount: 2}}
+		var b bytes.Buffer
+		err := WriteSlice(
+			NewEncoderWithOptions(&b, o),
+			s,
+			(*Encoder).WriteString)
+		var e *ErrTooLong
+		if !errors.As(err, &e) {
+			t.Fatalf("expected ErrTooLong but got '%v'", err)
+		}
+		if err := WriteStrings(&b, s); err != nil {
+			t.Fatal(err)
+		}
+		_, err = ReadSlice(
+			NewDecoderWithOptions(&b, o),
+			(*Decoder).ReadString)
+		var l *ErrLimitExceeded
+		if !errors.As(err, &l) {
+			t.Fatalf("expected ErrLimitExceeded but got '%v'", err)
+		}
+	})
+}
+
+// TestMarshallers verifies slices of types with pointer receivers round trip
+// and match the bytes of WriteMarshaller for each element.
+func TestMarshallers(t *testing.T) {
+	v := []testMarshaller{{"A"}, {""}, {"BC"}}
+	var b, w bytes.Buffer
+	if err := WriteMarshallers(NewEncoder(&b), v); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteCount(&w, len(v)); err != nil {
+		t.Fatal(err)
+	}
+	for i := range v {
+		if err := WriteMarshaller(&w, &v[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(b.Bytes(), w.Bytes()) {
+		t.Fatal("WriteMarshallers produced different bytes")
+	}
+	r, err := ReadMarshallers[testMarshaller](NewDecoder(&b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r, v) {
+		t.Fatalf("'%v' != '%v'", r, v)
+	}
+}
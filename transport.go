@@ -0,0 +1,116 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"strings"
+)
+
+// The base32 encoding used by EncodeToBase32 without padding.
+var base32Encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// EncodeToURL returns the data, usually the bytes written by an Encoder, as
+// unpadded URL safe base64 (RFC 4648 section 5) that can be used in query
+// strings and paths without escaping. Returns ErrTooLong if the result exceeds
+// IoMaxURLLength characters.
+func EncodeToURL(data []byte) (string, error) {
+	return encodeToString(base64.RawURLEncoding, "url", data, IoMaxURLLength)
+}
+
+// DecodeFromURL returns the bytes encoded by EncodeToURL. Trailing padding is
+// accepted for compatibility with padded encoders. Returns ErrLimitExceeded if
+// the string exceeds IoMaxURLLength characters.
+func DecodeFromURL(s string) ([]byte, error) {
+	return decodeString(
+		base64.RawURLEncoding,
+		"IoMaxURLLength",
+		s,
+		IoMaxURLLength)
+}
+
+// EncodeToCookie returns the data as unpadded URL safe base64 in the same way
+// as EncodeToURL. The characters are all valid in a cookie value. Returns
+// ErrTooLong if the result exceeds IoMaxCookieLength characters.
+func EncodeToCookie(data []byte) (string, error) {
+	return encodeToString(
+		base64.RawURLEncoding,
+		"cookie",
+		data,
+		IoMaxCookieLength)
+}
+
+// DecodeFromCookie returns the bytes encoded by EncodeToCookie. Returns
+// ErrLimitExceeded if the string exceeds IoMaxCookieLength characters.
+func DecodeFromCookie(s string) ([]byte, error) {
+	return decodeString(
+		base64.RawURLEncoding,
+		"IoMaxCookieLength",
+		s,
+		IoMaxCookieLength)
+}
+
+// EncodeToBase32 returns the data as unpadded upper case base32 (RFC 4648
+// section 6) for use where values are not case sensitive, for example host
+// names. Returns ErrTooLong if the result exceeds IoMaxURLLength characters.
+func EncodeToBase32(data []byte) (string, error) {
+	return encodeToString(base32Encoding, "base32", data, IoMaxURLLength)
+}
+
+// DecodeFromBase32 returns the bytes encoded by EncodeToBase32. Lower case
+// characters and trailing padding are accepted. Returns ErrLimitExceeded if
+// the string exceeds IoMaxURLLength characters.
+func DecodeFromBase32(s string) ([]byte, error) {
+	if len(s) <= IoMaxURLLength {
+		s = strings.ToUpper(s)
+	}
+	return decodeString(base32Encoding, "IoMaxURLLength", s, IoMaxURLLength)
+}
+
+// textEncoding is implemented by base32.Encoding and base64.Encoding.
+type textEncoding interface {
+	EncodedLen(n int) int
+	EncodeToString(src []byte) string
+	DecodeString(s string) ([]byte, error)
+}
+
+// encodeToString returns the data encoded with the encoding or ErrTooLong if
+// the result would exceed m characters. f is the name used in the error.
+func encodeToString(
+	e textEncoding,
+	f string,
+	data []byte,
+	m int) (string, error) {
+	if l := e.EncodedLen(len(data)); l > m {
+		return "", &ErrTooLong{Field: f, Length: int64(l), Limit: int64(m)}
+	}
+	return e.EncodeToString(data), nil
+}
+
+// decodeString returns the bytes decoded from s ignoring any trailing padding
+// or ErrLimitExceeded if s is longer than m characters. n is the name of the
+// limit used in the error.
+func decodeString(e textEncoding, n string, s string, m int) ([]byte, error) {
+	if len(s) > m {
+		return nil, &ErrLimitExceeded{
+			Limit: n,
+			Value: int64(len(s)),
+			Max:   int64(m)}
+	}
+	return e.DecodeString(strings.TrimRight(s, "="))
+}
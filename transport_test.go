@@ -0,0 +1,124 @@
+/* ****************************************************************************
+ * Copyright 2022 51 Degrees Mobile Experts Limited (51degrees.com)
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License"); you may not
+ * use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+ * WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+ * License for the specific language governing permissions and limitations
+ * under the License.
+ * ***************************************************************************/
+
+package common
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestTransport verifies codec output round trips through each text encoding
+// and that the encoded characters need no escaping.
+func TestTransport(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteString(&b, "Hello?&="); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteByteArray(&b, []byte{0xfb, 0xff, 0xfe}); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		name   string
+		encode func([]byte) (string, error)
+		decode func(string) ([]byte, error)
+		chars  string
+	}{
+		{"url", EncodeToURL, DecodeFromURL, "+/="},
+		{"cookie", EncodeToCookie, DecodeFromCookie, "+/=;, \""},
+		{"base32", EncodeToBase32, DecodeFromBase32, "=abcdefghijklmnopqrstuvwxyz"},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			s, err := c.encode(b.Bytes())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.ContainsAny(s, c.chars) {
+				t.Fatalf("'%s' contains one of '%s'", s, c.chars)
+			}
+			r, err := c.decode(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(r, b.Bytes()) {
+				t.Fatal("decoded bytes differ")
+			}
+			if _, err := c.decode(s + "!"); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+// TestTransportCompatibility verifies padded and lower case input from other
+// encoders is accepted.
+func TestTransportCompatibility(t *testing.T) {
+	r, err := DecodeFromURL("-_8=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r, []byte{0xfb, 0xff}) {
+		t.Fatalf("'%v' incorrect", r)
+	}
+	r, err = DecodeFromBase32("my======")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(r) != "f" {
+		t.Fatalf("'%v' incorrect", r)
+	}
+}
+
+// TestTransportLimits verifies values longer than the browser limits are
+// rejected when encoded and decoded.
+func TestTransportLimits(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		encode func([]byte) (string, error)
+		decode func(string) ([]byte, error)
+		max    int
+		fits   int // the largest number of bytes that fit in max
+	}{
+		{"url", EncodeToURL, DecodeFromURL, IoMaxURLLength, 1536},
+		{"cookie", EncodeToCookie, DecodeFromCookie, IoMaxCookieLength, 3072},
+		{"base32", EncodeToBase32, DecodeFromBase32, IoMaxURLLength, 1280},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			s, err := c.encode(make([]byte, c.fits))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(s) != c.max {
+				t.Fatalf("length '%d' not '%d'", len(s), c.max)
+			}
+			if _, err := c.decode(s); err != nil {
+				t.Fatal(err)
+			}
+			_, err = c.encode(make([]byte, c.fits+1))
+			var e *ErrTooLong
+			if !errors.As(err, &e) || e.Limit != int64(c.max) {
+				t.Fatalf("expected ErrTooLong but got '%v'", err)
+			}
+			_, err = c.decode(s + "A")
+			var l *ErrLimitExceeded
+			if !errors.As(err, &l) || l.Max != int64(c.max) {
+				t.Fatalf("expected ErrLimitExceeded but got '%v'", err)
+			}
+		})
+	}
+}